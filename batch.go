@@ -0,0 +1,56 @@
+package nosqlorm
+
+import (
+	"context"
+	"github.com/gocql/gocql"
+	"reflect"
+)
+
+type cqlBatch[T interface{}] struct {
+	sess         *gocql.Session
+	batch        *gocql.Batch
+	writeOptions writeOptions
+	table        string
+}
+
+// NewBatch Create a batch builder, use gocql.LoggedBatch, gocql.UnloggedBatch or gocql.CounterBatch as batch type.
+func (ctx *cqlOrm[T]) NewBatch(batchType gocql.BatchType) Batch[T] {
+	return &cqlBatch[T]{sess: ctx.sess, batch: ctx.sess.NewBatch(batchType), writeOptions: ctx.opts.writeOptions, table: ctx.table()}
+}
+
+func (b *cqlBatch[T]) Insert(obj T, options ...WriteOption) error {
+	sql, sqlValues, err := buildInsertStatement(reflect.ValueOf(obj), statementOptions{writeOptions: b.writeOptions.with(options), table: b.table})
+	if err != nil {
+		return err
+	}
+	b.batch.Query(sql, sqlValues...)
+	return nil
+}
+
+func (b *cqlBatch[T]) Update(obj T, options ...WriteOption) error {
+	sql, sqlValues, err := buildUpdateStatement(reflect.ValueOf(obj), statementOptions{writeOptions: b.writeOptions.with(options), table: b.table})
+	if err != nil {
+		return err
+	}
+	b.batch.Query(sql, sqlValues...)
+	return nil
+}
+
+func (b *cqlBatch[T]) Delete(obj T) error {
+	sql, sqlValues, err := buildDeleteStatement(reflect.ValueOf(obj), statementOptions{table: b.table})
+	if err != nil {
+		return err
+	}
+	b.batch.Query(sql, sqlValues...)
+	return nil
+}
+
+// Exec Run all collected statements as one batch.
+func (b *cqlBatch[T]) Exec() error {
+	return b.ExecCtx(context.Background())
+}
+
+// ExecCtx is the same as Exec, the batch is bound to the given context for cancellation and deadlines.
+func (b *cqlBatch[T]) ExecCtx(c context.Context) error {
+	return b.sess.ExecuteBatch(b.batch.WithContext(c))
+}
@@ -1,11 +1,12 @@
 package nosqlorm
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/gocql/gocql"
-	"log"
 	"reflect"
+	"regexp"
 	"slices"
 	"strings"
 	"sync"
@@ -18,9 +19,24 @@ const jsonTAG = "json"
 
 var modelCache sync.Map
 
+// Unquoted table name, optionally qualified by a keyspace
+var tableNamePattern = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9_]*\.)?[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// Unquoted identifier such as a keyspace or index name
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
 type tableSchema struct {
-	fields   []string
-	fieldMap map[string]tableField
+	// Default table name, see ModelTableName
+	tableName      string
+	fields         []string
+	fieldMap       map[string]tableField
+	partitionKeys  []string
+	clusteringKeys []string
+	defaultTTL     *time.Duration
+	// Generated accessors, nil if no mapper was registered for the model
+	mapper *modelMapper
+	// Columns and table options as rendered into DDL
+	definition tableDefinition
 }
 
 type tableField struct {
@@ -28,294 +44,710 @@ type tableField struct {
 	isPartitionKey  bool
 	isClusteringKey bool
 	isStatic        bool
+	isCounter       bool
+	dbType          string
 	dataType        reflect.Kind
 	isPointer       bool
 	isList          bool
-	offSet          uintptr
+	// Kind of the secondary index on the column, empty if not indexed
+	index  string
+	offSet uintptr
 }
 
 type cqlOrm[T interface{}] struct {
 	sess *gocql.Session
+	opts ormOptions
+}
+
+type ormOptions struct {
+	serialConsistency gocql.SerialConsistency
+	writeOptions      writeOptions
+	verifySchema      bool
+	// Overrides the table name of the model if not empty
+	tableName string
+	// Qualifies table names in statements if not empty, see InKeyspace
+	keyspace string
+	// Overrides the base table of a view if not empty, see WithBaseTableName
+	baseTableName string
+}
+
+// OrmOption Customize an ORM object created by NewCqlOrm
+type OrmOption func(*ormOptions)
+
+// WithSerialConsistency Set the serial consistency used by lightweight transactions, default is gocql.Serial.
+func WithSerialConsistency(cons gocql.SerialConsistency) OrmOption {
+	return func(opts *ormOptions) {
+		opts.serialConsistency = cons
+	}
 }
 
-// NewCqlOrm Create a new object to access specific Cassandra table
-func NewCqlOrm[T interface{}](session *gocql.Session) (*cqlOrm[T], error) {
+// WithTableName Bind the ORM object to an explicit table, such as a monthly or sharded table backed by the same model.
+// The name is lower-cased like an unquoted CQL identifier.
+func WithTableName(tableName string) OrmOption {
+	return func(opts *ormOptions) {
+		opts.tableName = strings.ToLower(tableName)
+	}
+}
+
+// NewCqlOrm Create a new object to access specific Cassandra table, use NewCqlView for materialized views.
+func NewCqlOrm[T interface{}](session *gocql.Session, options ...OrmOption) (*cqlOrm[T], error) {
+	var t T
+	if _, ok := interface{}(&t).(ModelView); ok {
+		return nil, errors.New(fmt.Sprintf("Invalid model %T: %s, use NewCqlView", t, ErrReadOnlyView.Error()))
+	}
+	return newCqlOrm[T](session, options...)
+}
+
+func newCqlOrm[T interface{}](session *gocql.Session, options ...OrmOption) (*cqlOrm[T], error) {
 	// Cache table schema to memory
 	var t T
 	typ := reflect.TypeOf(t)
-	typName := typ.String()
 
 	if typ.Kind() != reflect.Struct {
 		panic("Must be struct")
 	}
 
-	if _, existing := modelCache.Load(typName); !existing {
-		schema := tableSchema{
-			fields:   make([]string, 0),
-			fieldMap: make(map[string]tableField),
+	schema, err := registerModel(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	orm := &cqlOrm[T]{sess: session, opts: ormOptions{serialConsistency: gocql.Serial}}
+	for _, option := range options {
+		option(&orm.opts)
+	}
+	if orm.opts.keyspace != "" {
+		if err := validateKeyspaceName(orm.opts.keyspace); err != nil {
+			return nil, err
+		}
+	}
+	if orm.opts.tableName != "" && !identifierPattern.MatchString(orm.opts.tableName) {
+		return nil, errors.New(fmt.Sprintf("Invalid table name %s", orm.opts.tableName))
+	}
+	if orm.opts.baseTableName != "" {
+		if schema.definition.base == nil {
+			return nil, errors.New(fmt.Sprintf("Invalid table %s: only views have a base table", orm.tableName()))
+		}
+		if !identifierPattern.MatchString(orm.opts.baseTableName) {
+			return nil, errors.New(fmt.Sprintf("Invalid base table name %s", orm.opts.baseTableName))
+		}
+	}
+	if orm.opts.verifySchema {
+		if err := verifyTableSchema(session, orm.keyspace(), orm.tableName(), schema); err != nil {
+			return nil, err
 		}
-		for i := 0; i < typ.NumField(); i++ {
-			field := typ.Field(i)
-			tag := field.Tag
+	}
+	return orm, nil
+}
 
-			// Validate tag
-			fieldName := getFieldName(tag)
-			if fieldName == "" {
-				return nil, errors.New("invalid CQL Tag: must have a json name")
-			}
-			if !isValidCqlTag(tag) {
-				return nil, errors.New(fmt.Sprintf("Invalid CQL Tag for filed %s: %s", fieldName, tag.Get(cqlTAG)))
-			}
+// RegisterModels Cache table schema of models, so that they could be used by non-generic APIs such as UnitOfWork.
+func RegisterModels(models ...interface{}) error {
+	for _, model := range models {
+		typ := reflect.TypeOf(model)
+		if typ == nil || typ.Kind() != reflect.Struct {
+			return errors.New(fmt.Sprintf("Invalid model %v: must be struct", model))
+		}
+		if _, err := registerModel(typ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			// Validate whether it is valid type
-			dbType, isPointer, err := getFieldDBType(field.Type.String(), isDateFiled(tag))
-			if err != nil {
-				log.Fatal("Invalid field type " + field.Type.String())
-				return nil, err
-			}
-			fieldType := field.Type.Kind()
-			if isPointer {
-				fieldType = field.Type.Elem().Kind()
-			}
-			if strings.HasPrefix(dbType, "list") {
-				fieldType = field.Type.Elem().Kind()
-			}
+// Parse struct tags of a model type and cache its table schema, cached schema is returned if existing.
+func registerModel(typ reflect.Type) (tableSchema, error) {
+	typName := typ.String()
+	if schema, existing := modelCache.Load(typName); existing {
+		return schema.(tableSchema), nil
+	}
 
-			schema.fields = append(schema.fields, fieldName)
-			schema.fieldMap[fieldName] = tableField{
-				fieldName:       fieldName,
-				isPartitionKey:  isPartitionKey(tag),
-				isClusteringKey: isClusterKey(tag),
-				isStatic:        isStaticFiled(tag),
-				dataType:        fieldType,
-				isPointer:       isPointer,
-				isList:          strings.HasPrefix(dbType, "list"),
-				offSet:          field.Offset,
-			}
-		}
-		modelCache.Store(typName, schema)
+	schema := tableSchema{
+		tableName: modelTableName(typ),
+		fields:    make([]string, 0),
+		fieldMap:  make(map[string]tableField),
 	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag
+
+		// Validate tag
+		fieldName := getFieldName(tag)
+		if fieldName == "" {
+			return tableSchema{}, errors.New("invalid CQL Tag: must have a json name")
+		}
+		if err := validateCqlTag(tag); err != nil {
+			return tableSchema{}, errors.New(fmt.Sprintf("Invalid CQL Tag for filed %s: %s", fieldName, err.Error()))
+		}
 
-	orm := &cqlOrm[T]{sess: session}
-	return orm, nil
+		// Validate whether it is valid type
+		dbType, isPointer, err := getColumnDBType(field)
+		if err != nil {
+			return tableSchema{}, errors.New(fmt.Sprintf("Invalid field type of %s: %s", fieldName, err.Error()))
+		}
+		fieldType := field.Type.Kind()
+		if isPointer {
+			fieldType = field.Type.Elem().Kind()
+		}
+		if strings.HasPrefix(dbType, "list") {
+			fieldType = field.Type.Elem().Kind()
+		}
+
+		schema.fields = append(schema.fields, fieldName)
+		if isPartitionKey(tag) && fieldName != "-" {
+			schema.partitionKeys = append(schema.partitionKeys, fieldName)
+		}
+		if isClusterKey(tag) && fieldName != "-" {
+			schema.clusteringKeys = append(schema.clusteringKeys, fieldName)
+		}
+		schema.fieldMap[fieldName] = tableField{
+			fieldName:       fieldName,
+			isPartitionKey:  isPartitionKey(tag),
+			isClusteringKey: isClusterKey(tag),
+			isStatic:        isStaticFiled(tag),
+			isCounter:       isCounterField(tag),
+			dbType:          dbType,
+			dataType:        fieldType,
+			isPointer:       isPointer,
+			isList:          strings.HasPrefix(dbType, "list"),
+			index:           indexKind(tag),
+			offSet:          field.Offset,
+		}
+	}
+	definition, err := typeTableDefinition(typ)
+	if err != nil {
+		return tableSchema{}, err
+	}
+	schema.definition = definition
+	if mapper, ok := mapperCache.Load(typName); ok && mapper.(*modelMapper).matches(schema) {
+		schema.mapper = mapper.(*modelMapper)
+	}
+	if model, ok := reflect.New(typ).Interface().(ModelTTL); ok {
+		ttl := model.DefaultTTL()
+		schema.defaultTTL = &ttl
+	}
+	modelCache.Store(typName, schema)
+	return schema, nil
 }
 
-// Auto create or update table for Cassandra
+// Auto create or update table for Cassandra, columns added to a model are added to the existing table.
+// Incompatible changes such as column type or primary key changes are not applied but returned as a *SchemaError.
 func CreateCassandraTables(sess *gocql.Session, tables ...interface{}) error {
-	for _, table := range tables {
-		typ := reflect.TypeOf(table)
-		tableName := strings.ToLower(typ.Name())
-		fields := make([]string, 0)
-		pkKeys := make([]string, 0)
-		ckKeys := make([]string, 0)
-		for i := 0; i < typ.NumField(); i++ {
-			tag := typ.Field(i).Tag
-			filedName := getFieldName(tag)
-			if filedName == "-" {
-				continue
-			}
-			filedDBType, _, err := getFieldDBType(typ.Field(i).Type.String(), isDateFiled(tag))
-			if err != nil {
-				return err
-			}
+	return CreateCassandraTablesInKeyspace(sess, "", tables...)
+}
 
-			isStatic := ""
-			if isStaticFiled(tag) {
-				isStatic = " static"
-			}
-			fields = append(fields, fmt.Sprintf("%s %s%s", filedName, filedDBType, isStatic))
-			if isPartitionKey(tag) {
-				pkKeys = append(pkKeys, filedName)
-			}
-			if isClusterKey(tag) {
-				ckKeys = append(ckKeys, filedName)
-			}
+// CreateCassandraTablesInKeyspace is the same as CreateCassandraTables in the target keyspace,
+// an empty keyspace means the keyspace of the session.
+func CreateCassandraTablesInKeyspace(sess *gocql.Session, keyspace string, tables ...interface{}) error {
+	if keyspace != "" {
+		if err := validateKeyspaceName(keyspace); err != nil {
+			return err
+		}
+	}
+	for _, table := range tables {
+		def, err := modelTableDefinition(table)
+		if err != nil {
+			return err
 		}
-		fieldSql := strings.Join(fields, ", ")
-		pkSql := strings.Join(pkKeys, ", ")
-		if len(pkKeys) > 1 {
-			pkSql = "(" + pkSql + ")"
-		} else if len(pkKeys) == 0 {
-			panic(tableName + " must have at least one Partition Key")
+		def.keyspace = strings.ToLower(keyspace)
+		if err := createCassandraTable(sess, def); err != nil {
+			return err
 		}
-		ckSql := strings.Join(ckKeys, ", ")
-		if len(ckKeys) > 0 {
-			ckSql = ", " + ckSql
+	}
+	return nil
+}
+
+func createCassandraTable(sess *gocql.Session, def tableDefinition) error {
+	keyspace := def.keyspace
+	if keyspace == "" {
+		keyspace = sess.Query("").Keyspace()
+	}
+	live, err := readTableColumns(sess, keyspace, def.name)
+	if err != nil {
+		return err
+	}
+	if len(live) > 0 && def.base != nil {
+		// Views could not be altered
+		if mismatches := diffTableColumns(def.columns, live); len(mismatches) > 0 {
+			return &SchemaError{Table: def.qualifiedName(), Mismatches: mismatches}
 		}
-		sql := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s, PRIMARY KEY (%s%s));", tableName, fieldSql, pkSql, ckSql)
-		println(sql)
-		err := sess.Query(sql).Exec()
+		return nil
+	}
+	if len(live) > 0 {
+		return alterCassandraTable(sess, keyspace, def, live)
+	}
+
+	statements, err := def.createStatements()
+	if err != nil {
+		return err
+	}
+	for _, sql := range statements {
+		logDDL(sql)
+		err = sess.Query(sql).Exec()
 		if err != nil {
-			return errors.New(fmt.Sprintf("Create Cassandra Tables %s failed: %s\n", tableName, err.Error()))
+			return errors.New(fmt.Sprintf("Create Cassandra Tables %s failed: %s\n", def.qualifiedName(), err.Error()))
 		}
-		fmt.Printf("Create Cassandra table %s success\n", tableName)
 	}
 	return nil
 }
 
-func (ctx *cqlOrm[T]) Insert(obj T) error {
-	val := reflect.ValueOf(obj)
-	typ := val.Type()
-	tableName := strings.ToLower(typ.Name())
+func alterCassandraTable(sess *gocql.Session, keyspace string, def tableDefinition, live []liveColumn) error {
+	tableName := def.qualifiedName()
+	statements, err := alterTableStatements(tableName, def.columns, live)
+	if err != nil {
+		return err
+	}
+	liveIndexes, err := readTableIndexes(sess, keyspace, def.name)
+	if err != nil {
+		return err
+	}
+	indexStatements, err := createIndexStatements(def, liveIndexes)
+	if err != nil {
+		return err
+	}
+	statements = append(statements, indexStatements...)
+	for _, sql := range statements {
+		logDDL(sql)
+		if err := sess.Query(sql).Exec(); err != nil {
+			return errors.New(fmt.Sprintf("Alter Cassandra Tables %s failed: %s\n", tableName, err.Error()))
+		}
+	}
+	return nil
+}
 
-	schema, ok := modelCache.Load(typ.String())
-	if !ok {
-		return errors.New(fmt.Sprintf("Table %s not found", tableName))
+// Receives the DDL statements run by CreateCassandraTables, nil means nothing is logged
+var ddlLogger func(statement string)
+
+// SetDDLLogger Receive every CREATE and ALTER statement run by CreateCassandraTables, nil turns logging off.
+// It should be set before tables are created.
+func SetDDLLogger(logger func(statement string)) {
+	ddlLogger = logger
+}
+
+func logDDL(statement string) {
+	if ddlLogger != nil {
+		ddlLogger(statement)
 	}
-	tableFields := schema.(tableSchema).fields
+}
 
-	insertFields := make([]string, 0)
-	fieldPlaceHolders := make([]string, 0)
-	sqlValues := make([]interface{}, 0)
-	for i := range tableFields {
-		if tableFields[i] == "-" {
-			continue
-		}
-		fieldVal := convertToNormalValue(val.Field(i))
-		if fieldVal == nil {
-			continue
+func (ctx *cqlOrm[T]) Insert(obj T, options ...WriteOption) error {
+	return ctx.InsertCtx(context.Background(), obj, options...)
+}
+
+// InsertCtx is the same as Insert, the query is bound to the given context for cancellation and deadlines.
+func (ctx *cqlOrm[T]) InsertCtx(c context.Context, obj T, options ...WriteOption) error {
+	sql, sqlValues, err := buildInsertStatement(reflect.ValueOf(obj), statementOptions{writeOptions: ctx.opts.writeOptions.with(options), table: ctx.table()})
+	if err != nil {
+		return err
+	}
+	return ctx.sess.Query(sql, sqlValues...).WithContext(c).Exec()
+}
+
+func (ctx *cqlOrm[T]) Select(obj T) ([]T, error) {
+	return ctx.SelectCtx(context.Background(), obj)
+}
+
+// SelectCtx is the same as Select, the query is bound to the given context for cancellation and deadlines.
+func (ctx *cqlOrm[T]) SelectCtx(c context.Context, obj T) ([]T, error) {
+	sql, selectFields, whereValues, err := buildSelectStatement(reflect.ValueOf(obj), ctx.table(), nil)
+	if err != nil {
+		return []T{}, err
+	}
+	schema, _, _ := loadTableSchema(reflect.TypeOf(obj), ctx.table())
+
+	// Errors such as a cancelled context or a passed deadline are reported by Close, rows scanned so far are dropped
+	iter := ctx.sess.Query(sql, whereValues...).WithContext(c).Iter()
+	rows := scanRows[T](iter, selectFields, schema)
+	if err := iter.Close(); err != nil {
+		return []T{}, err
+	}
+	return rows, nil
+}
+
+// Scan all rows of an iterator into model objects
+func scanRows[T interface{}](iter *gocql.Iter, selectFields []string, schema tableSchema) []T {
+	selectResult := make([]T, 0)
+	for {
+		var tableObj T
+		if !iter.Scan(fieldPointers(&tableObj, selectFields, schema)...) {
+			break
 		}
-		insertFields = append(insertFields, tableFields[i])
-		fieldPlaceHolders = append(fieldPlaceHolders, "?")
-		sqlValues = append(sqlValues, fieldVal)
+		selectResult = append(selectResult, tableObj)
 	}
-	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", tableName, strings.Join(insertFields, ","), strings.Join(fieldPlaceHolders, ","))
+	return selectResult
+}
 
-	return ctx.sess.Query(sql, sqlValues...).Exec()
+func (ctx *cqlOrm[T]) Update(obj T, options ...WriteOption) error {
+	return ctx.UpdateCtx(context.Background(), obj, options...)
 }
 
-func (ctx *cqlOrm[T]) Select(obj T) ([]T, error) {
-	val := reflect.ValueOf(obj)
-	typ := val.Type()
-	tableName := strings.ToLower(typ.Name())
+// UpdateCtx is the same as Update, the query is bound to the given context for cancellation and deadlines.
+func (ctx *cqlOrm[T]) UpdateCtx(c context.Context, obj T, options ...WriteOption) error {
+	sql, sqlParams, err := buildUpdateStatement(reflect.ValueOf(obj), statementOptions{writeOptions: ctx.opts.writeOptions.with(options), table: ctx.table()})
+	if err != nil {
+		return err
+	}
+	return ctx.sess.Query(sql, sqlParams...).WithContext(c).Exec()
+}
+
+func (ctx *cqlOrm[T]) Delete(obj T) error {
+	return ctx.DeleteCtx(context.Background(), obj)
+}
+
+// DeleteCtx is the same as Delete, the query is bound to the given context for cancellation and deadlines.
+func (ctx *cqlOrm[T]) DeleteCtx(c context.Context, obj T) error {
+	sql, whereValues, err := buildDeleteStatement(reflect.ValueOf(obj), statementOptions{table: ctx.table()})
+	if err != nil {
+		return err
+	}
+	return ctx.sess.Query(sql, whereValues...).WithContext(c).Exec()
+}
 
+// Table the ORM object is bound to, the name has been validated by newCqlOrm
+func (ctx *cqlOrm[T]) tableName() string {
+	if ctx.opts.tableName != "" {
+		return ctx.opts.tableName
+	}
+	var t T
+	return modelTableName(reflect.TypeOf(t))
+}
+
+// Load cached table schema of a model type and the table name, a non-empty table overrides the model table name
+func loadTableSchema(typ reflect.Type, table string) (tableSchema, string, error) {
 	schema, ok := modelCache.Load(typ.String())
-	tableFields := schema.(tableSchema).fields
 	if !ok {
-		return []T{}, errors.New(fmt.Sprintf("Table %s not found", tableName))
+		return tableSchema{}, modelTableName(typ), errors.New(fmt.Sprintf("Table %s not found", modelTableName(typ)))
+	}
+	if table != "" {
+		if !tableNamePattern.MatchString(table) {
+			return tableSchema{}, table, errors.New(fmt.Sprintf("Invalid table name %s", table))
+		}
+		return schema.(tableSchema), table, nil
+	}
+	return schema.(tableSchema), schema.(tableSchema).tableName, nil
+}
+
+// Table name of a model type, lower-cased type name unless the model implements ModelTableName.
+// Names are lower-cased like Cassandra does for unquoted identifiers, so they match system_schema.
+func modelTableName(typ reflect.Type) string {
+	if model, ok := reflect.New(typ).Interface().(ModelTableName); ok && model.TableName() != "" {
+		return strings.ToLower(model.TableName())
+	}
+	return strings.ToLower(typ.Name())
+}
+
+// Extra clauses of a write statement
+type statementOptions struct {
+	writeOptions
+	ifNotExists bool
+	ifExists    bool
+	conditions  []Condition
+	// Explicitly named columns, nil means all non-nil fields
+	mask map[string]bool
+	// Overrides the table name of the model if not empty
+	table string
+}
+
+// Render the IF clause of a lightweight transaction, empty if it is a normal write.
+func (opts statementOptions) renderIf(schema tableSchema) (string, []interface{}, error) {
+	if opts.timestamp != nil && (opts.ifNotExists || opts.ifExists || len(opts.conditions) > 0) {
+		// Cassandra rejects them, the timestamp of a lightweight transaction is decided by Paxos
+		return "", nil, errors.New("Custom write timestamps are not allowed on lightweight transactions")
+	}
+	switch {
+	case opts.ifNotExists:
+		return " IF NOT EXISTS", nil, nil
+	case opts.ifExists:
+		return " IF EXISTS", nil, nil
+	case len(opts.conditions) > 0:
+		clauses, values, err := renderConditions(schema, opts.conditions)
+		if err != nil {
+			return "", nil, err
+		}
+		return " IF " + strings.Join(clauses, " AND "), values, nil
+	}
+	return "", nil, nil
+}
+
+// Bind values of the IF clause, in the order they are rendered
+func (opts statementOptions) conditionValues() []interface{} {
+	values := make([]interface{}, 0, len(opts.conditions))
+	for _, cond := range opts.conditions {
+		values = append(values, cond.Value)
 	}
+	return values
+}
 
-	selectFields := make([]string, 0)
-	whereClause := make([]string, 0)
-	whereValues := make([]interface{}, 0)
+// Build INSERT statement and its values from a model object
+func buildInsertStatement(val reflect.Value, opts statementOptions) (string, []interface{}, error) {
+	schema, tableName, err := loadWritableSchema(val.Type(), opts.table)
+	if err != nil {
+		return "", nil, err
+	}
 
-	for i, fieldName := range tableFields {
+	values := modelValues(val, schema)
+	shape := newColumnShape(len(schema.fields))
+	sqlValues := make([]interface{}, 0, len(schema.fields))
+	for i, fieldName := range schema.fields {
 		if fieldName == "-" {
 			continue
 		}
-		selectFields = append(selectFields, fieldName)
-		if schema.(tableSchema).fieldMap[fieldName].isClusteringKey || schema.(tableSchema).fieldMap[fieldName].isPartitionKey {
-			fieldVal := convertToNormalValue(val.Field(i))
-			if fieldVal == nil {
+		field := schema.fieldMap[fieldName]
+		var fieldVal interface{}
+		if field.isPartitionKey || field.isClusteringKey {
+			if fieldVal = values[i]; fieldVal == nil {
+				continue
+			}
+		} else {
+			var ok bool
+			if fieldVal, ok = opts.writeValue(values[i], field); !ok {
 				continue
 			}
-			whereClause = append(whereClause, fmt.Sprintf("%s=?", fieldName))
-			whereValues = append(whereValues, fieldVal)
 		}
+		shape.set(i)
+		sqlValues = append(sqlValues, fieldVal)
+	}
+	usingValues, err := opts.usingValues(schema)
+	if err != nil {
+		return "", nil, err
 	}
 
-	sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s;", strings.Join(selectFields, ", "), tableName, strings.Join(whereClause, " AND "))
-	selectResult := make([]T, 0)
-	iter := ctx.sess.Query(sql, whereValues...).Iter()
-	defer func() {
-		err := iter.Close()
+	key := statementKey{model: val.Type().String(), statement: "INSERT", shape: shape.String(), clauses: opts.cacheKey()}
+	sql, err := cachedStatement(key, tableName, func(tableRef string) (string, error) {
+		insertFields := shape.columns(schema)
+		if err := checkPrimaryKey(schema, tableName, "INSERT", toMask(insertFields), true); err != nil {
+			return "", err
+		}
+		ifClause, _, err := opts.renderIf(schema)
 		if err != nil {
-			fmt.Printf("Select %s failed: %s\n", tableName, err.Error())
+			return "", err
 		}
-	}()
-	for {
-		var tableObj T
-		if !iter.Scan(getPointersOfStructElements(unsafe.Pointer(&tableObj), selectFields, schema.(tableSchema).fieldMap)...) {
-			break
+		usingClause := opts.renderUsing(schema)
+		fieldPlaceHolders := strings.TrimSuffix(strings.Repeat("?,", len(insertFields)), ",")
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)%s%s;", tableRef, strings.Join(insertFields, ","), fieldPlaceHolders, ifClause, usingClause), nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, append(sqlValues, usingValues...), nil
+}
+
+// Build SELECT statement from a model object, non-nil key fields are used as filter unless a field mask is given
+func buildSelectStatement(val reflect.Value, table string, fieldMask *FieldMask) (string, []string, []interface{}, error) {
+	schema, tableName, err := loadTableSchema(val.Type(), table)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	var mask map[string]bool
+	projection := ""
+	if fieldMask != nil {
+		if err := checkMaskColumns(schema, fieldMask.Filter, true); err != nil {
+			return "", nil, nil, err
 		}
-		selectResult = append(selectResult, tableObj)
+		mask = toMask(fieldMask.Filter)
+		projection = strings.Join(fieldMask.Columns, ",")
 	}
 
-	return selectResult, nil
-}
+	values := modelValues(val, schema)
+	shape := newColumnShape(len(schema.fields))
+	whereValues := make([]interface{}, 0, len(schema.partitionKeys)+len(schema.clusteringKeys))
+	for i, fieldName := range schema.fields {
+		if fieldName == "-" {
+			continue
+		}
+		if schema.fieldMap[fieldName].isClusteringKey || schema.fieldMap[fieldName].isPartitionKey {
+			fieldVal, ok := maskedFieldValue(values[i], fieldName, mask)
+			if !ok || fieldVal == nil {
+				continue
+			}
+			shape.set(i)
+			whereValues = append(whereValues, fieldVal)
+		}
+	}
 
-func (ctx *cqlOrm[T]) Update(obj T) error {
-	val := reflect.ValueOf(obj)
-	typ := val.Type()
-	tableName := strings.ToLower(typ.Name())
+	key := statementKey{model: val.Type().String(), statement: "SELECT", shape: shape.String(), clauses: projection}
+	entry, err := cachedSelect(key, tableName, func(tableRef string) (string, []string, error) {
+		whereFields := shape.columns(schema)
+		if err := checkPrimaryKey(schema, tableName, "SELECT", toMask(whereFields), false); err != nil {
+			return "", nil, err
+		}
+		selectFields := make([]string, 0, len(schema.fields))
+		for _, fieldName := range schema.fields {
+			if fieldName != "-" {
+				selectFields = append(selectFields, fieldName)
+			}
+		}
+		if fieldMask != nil && len(fieldMask.Columns) > 0 {
+			if err := checkColumns(schema, fieldMask.Columns); err != nil {
+				return "", nil, err
+			}
+			// The cached statement must not share the slice of the caller
+			selectFields = slices.Clone(fieldMask.Columns)
+		}
+		whereClause := make([]string, 0, len(whereFields))
+		for _, fieldName := range whereFields {
+			whereClause = append(whereClause, fieldName+"=?")
+		}
+		sql := fmt.Sprintf("SELECT %s FROM %s WHERE %s;", strings.Join(selectFields, ", "), tableRef, strings.Join(whereClause, " AND "))
+		return sql, selectFields, nil
+	})
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return entry.sql, entry.selectFields, whereValues, nil
+}
 
-	schema, ok := modelCache.Load(typ.String())
-	if !ok {
-		return errors.New(fmt.Sprintf("Table %s not found", tableName))
+// Build UPDATE statement and its values from a model object, key fields are used as filter
+func buildUpdateStatement(val reflect.Value, opts statementOptions) (string, []interface{}, error) {
+	schema, tableName, err := loadWritableSchema(val.Type(), opts.table)
+	if err != nil {
+		return "", nil, err
 	}
-	tableFields := schema.(tableSchema).fields
 
-	fields := make([]string, 0)
-	whereClause := make([]string, 0)
-	sqlValues := make([]interface{}, 0)
-	whereValues := make([]interface{}, 0)
-	for i, filedName := range tableFields {
+	values := modelValues(val, schema)
+	shape := newColumnShape(len(schema.fields))
+	sqlValues := make([]interface{}, 0, len(schema.fields))
+	whereValues := make([]interface{}, 0, len(schema.partitionKeys)+len(schema.clusteringKeys))
+	for i, filedName := range schema.fields {
 		if filedName == "-" {
 			continue
 		}
-		fieldVal := convertToNormalValue(val.Field(i))
-		if fieldVal == nil {
+		field := schema.fieldMap[filedName]
+		if field.isPartitionKey || field.isClusteringKey {
+			fieldVal := values[i]
+			if fieldVal == nil {
+				continue
+			}
+			shape.set(i)
+			whereValues = append(whereValues, fieldVal)
 			continue
 		}
-		if schema.(tableSchema).fieldMap[filedName].isPartitionKey || schema.(tableSchema).fieldMap[filedName].isClusteringKey {
-			whereClause = append(whereClause, fmt.Sprintf("%s=?", filedName))
-			whereValues = append(whereValues, fieldVal)
-		} else {
-			fields = append(fields, filedName+"=?")
-			sqlValues = append(sqlValues, fieldVal)
+		fieldVal, ok := opts.writeValue(values[i], field)
+		if !ok {
+			continue
 		}
+		shape.set(i)
+		sqlValues = append(sqlValues, fieldVal)
+	}
+	ifValues := opts.conditionValues()
+	usingValues, err := opts.usingValues(schema)
+	if err != nil {
+		return "", nil, err
 	}
 
-	sql := fmt.Sprintf("UPDATE %s SET %s WHERE %s;", tableName, strings.Join(fields, ","), strings.Join(whereClause, " AND "))
-	//fmt.Println(sql)
-	//fmt.Println(sqlValues)
-
-	sqlParams := sqlValues
+	key := statementKey{model: val.Type().String(), statement: "UPDATE", shape: shape.String(), clauses: opts.cacheKey()}
+	sql, err := cachedStatement(key, tableName, func(tableRef string) (string, error) {
+		fields := make([]string, 0)
+		whereClause := make([]string, 0)
+		restricted := make(map[string]bool)
+		onlyStatic := true
+		for _, filedName := range shape.columns(schema) {
+			field := schema.fieldMap[filedName]
+			switch {
+			case field.isPartitionKey || field.isClusteringKey:
+				whereClause = append(whereClause, fmt.Sprintf("%s=?", filedName))
+				restricted[filedName] = true
+			case field.isCounter:
+				// Counter columns could only be incremented or decremented
+				fields = append(fields, fmt.Sprintf("%s=%s+?", filedName, filedName))
+				onlyStatic = false
+			default:
+				fields = append(fields, filedName+"=?")
+				onlyStatic = onlyStatic && field.isStatic
+			}
+		}
+		// Static columns belong to the partition, updating only them does not need clustering keys
+		if err := checkPrimaryKey(schema, tableName, "UPDATE", restricted, !onlyStatic || len(fields) == 0); err != nil {
+			return "", err
+		}
+		ifClause, _, err := opts.renderIf(schema)
+		if err != nil {
+			return "", err
+		}
+		usingClause := opts.renderUsing(schema)
+		return fmt.Sprintf("UPDATE %s%s SET %s WHERE %s%s;", tableRef, usingClause, strings.Join(fields, ","), strings.Join(whereClause, " AND "), ifClause), nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	sqlParams := usingValues
+	sqlParams = append(sqlParams, sqlValues...)
 	sqlParams = append(sqlParams, whereValues...)
-	return ctx.sess.Query(sql, sqlParams...).Exec()
+	sqlParams = append(sqlParams, ifValues...)
+	return sql, sqlParams, nil
 }
 
-func (ctx *cqlOrm[T]) Delete(obj T) error {
-	val := reflect.ValueOf(obj)
-	typ := val.Type()
-	tableName := strings.ToLower(typ.Name())
-
-	schema, ok := modelCache.Load(typ.String())
-	if !ok {
-		return errors.New(fmt.Sprintf("Table %s not found", tableName))
+// Build DELETE statement and its values from a model object, key fields are used as filter
+func buildDeleteStatement(val reflect.Value, opts statementOptions) (string, []interface{}, error) {
+	schema, tableName, err := loadWritableSchema(val.Type(), opts.table)
+	if err != nil {
+		return "", nil, err
 	}
 
-	whereClause := make([]string, 0)
-	whereValues := make([]interface{}, 0)
-	for i, fieldName := range schema.(tableSchema).fields {
+	values := modelValues(val, schema)
+	shape := newColumnShape(len(schema.fields))
+	whereValues := make([]interface{}, 0, len(schema.partitionKeys)+len(schema.clusteringKeys))
+	for i, fieldName := range schema.fields {
 		if fieldName == "-" {
 			continue
 		}
-		if schema.(tableSchema).fieldMap[fieldName].isPartitionKey || schema.(tableSchema).fieldMap[fieldName].isClusteringKey {
-			fieldVal := convertToNormalValue(val.Field(i))
-			if fieldVal == nil {
+		if schema.fieldMap[fieldName].isPartitionKey || schema.fieldMap[fieldName].isClusteringKey {
+			fieldVal, ok := maskedFieldValue(values[i], fieldName, opts.mask)
+			if !ok || fieldVal == nil {
 				continue
 			}
-			whereClause = append(whereClause, fmt.Sprintf("%s=?", fieldName))
+			shape.set(i)
 			whereValues = append(whereValues, fieldVal)
 		}
 	}
+	ifValues := opts.conditionValues()
+
+	key := statementKey{model: val.Type().String(), statement: "DELETE", shape: shape.String(), clauses: opts.cacheKey()}
+	sql, err := cachedStatement(key, tableName, func(tableRef string) (string, error) {
+		whereFields := shape.columns(schema)
+		// Conditional deletes apply to a single row, so they need the full primary key
+		if err := checkPrimaryKey(schema, tableName, "DELETE", toMask(whereFields), opts.ifExists || len(opts.conditions) > 0); err != nil {
+			return "", err
+		}
+		ifClause, _, err := opts.renderIf(schema)
+		if err != nil {
+			return "", err
+		}
+		whereClause := make([]string, 0, len(whereFields))
+		for _, fieldName := range whereFields {
+			whereClause = append(whereClause, fmt.Sprintf("%s=?", fieldName))
+		}
+		return fmt.Sprintf("DELETE FROM %s WHERE %s%s;", tableRef, strings.Join(whereClause, " AND "), ifClause), nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, append(whereValues, ifValues...), nil
+}
 
-	sql := fmt.Sprintf("DELETE FROM %s WHERE %s;", tableName, strings.Join(whereClause, " AND "))
-	//fmt.Println(sql)
-	//fmt.Println(whereValues)
+// Mapping struct field to CS data type, counter columns must be integers
+func getColumnDBType(field reflect.StructField) (string, bool, error) {
+	return getTaggedDBType(field.Type.String(), field.Tag)
+}
 
-	return ctx.sess.Query(sql, whereValues...).Exec()
+func getTaggedDBType(typeName string, tag reflect.StructTag) (string, bool, error) {
+	dbType, isPointer, err := getFieldDBType(typeName, isDateFiled(tag))
+	if err != nil || !isCounterField(tag) {
+		return dbType, isPointer, err
+	}
+	if dbType != "bigint" && dbType != "int" && dbType != "smallint" && dbType != "tinyint" {
+		return "", isPointer, errors.New("Invalid counter type: " + typeName)
+	}
+	return "counter", isPointer, nil
 }
 
 // Mapping golang type to CS data type
 func getFieldDBType(typeName string, isDate bool) (string, bool, error) {
 	isPointer := false
-	if typeName[0:1] == "*" {
+	if strings.HasPrefix(typeName, "*") {
 		typeName = typeName[1:]
 		isPointer = true
 	}
-	if typeName[0:2] == "[]" {
+	if strings.HasPrefix(typeName, "[]") {
 		typeName = typeName[2:]
 		listDateType, _, _ := getFieldDBType(typeName, isDate)
 		return fmt.Sprintf("list<%s>", listDateType), isPointer, nil
@@ -350,53 +782,141 @@ func getFieldDBType(typeName string, isDate bool) (string, bool, error) {
 	}
 }
 
-func isValidCqlTag(tag reflect.StructTag) bool {
+func validateCqlTag(tag reflect.StructTag) error {
 	fieldName := tag.Get(jsonTAG)
 	tagStr := tag.Get(cqlTAG)
 
 	if fieldName == "-" && tagStr != "" {
-		log.Fatal("Json ignored field should not contain CQL tag: ", tagStr)
-		return false
+		return errors.New("Json ignored field should not contain CQL tag: " + tagStr)
 	}
 
 	if tagStr != "" {
-		keys := strings.Split(tagStr, ",")
-		allowKeys := []string{"pk", "ck", "static", "date"}
+		keys := cqlTagKeys(tag)
+		allowKeys := []string{"pk", "ck", "static", "date", "counter", "asc", "desc"}
 		for _, key := range keys {
-			if !slices.Contains(allowKeys, key) {
-				log.Fatal(fmt.Sprintf("Invalid tag: %s, key word %s is not allowed", tagStr, key))
-				return false
+			name, value, hasValue := strings.Cut(key, "=")
+			switch {
+			case name == "index" && hasValue && !slices.Contains([]string{"sai", "sasi", "legacy"}, value):
+				return errors.New(fmt.Sprintf("Invalid tag: %s, index kind must be sai, sasi or legacy", tagStr))
+			case name == "index_name" && !identifierPattern.MatchString(value):
+				return errors.New(fmt.Sprintf("Invalid tag: %s, index name %s is not a valid identifier", tagStr, value))
+			case name == "index" || name == "index_name":
+			case !slices.Contains(allowKeys, key):
+				return errors.New(fmt.Sprintf("Invalid tag: %s, key word %s is not allowed", tagStr, key))
 			}
 		}
 		isPk := isPartitionKey(tag)
 		isCk := isClusterKey(tag)
 		isStatic := isStaticFiled(tag)
 		if isPk && isCk {
-			log.Fatal(fmt.Sprintf("Invalid tag: %s, a field could not be both clustering key and partition key", tagStr))
-			return false
+			return errors.New(fmt.Sprintf("Invalid tag: %s, a field could not be both clustering key and partition key", tagStr))
 		}
 		if (isPk || isCk) && isStatic {
-			log.Fatal(fmt.Sprintf("Invalid tag: %s, static field could not be part of primary key", tagStr))
-			return false
+			return errors.New(fmt.Sprintf("Invalid tag: %s, static field could not be part of primary key", tagStr))
+		}
+		if (isPk || isCk) && isCounterField(tag) {
+			return errors.New(fmt.Sprintf("Invalid tag: %s, counter field could not be part of primary key", tagStr))
+		}
+		hasOrder := slices.Contains(keys, "asc") || slices.Contains(keys, "desc")
+		if hasOrder && !isCk {
+			return errors.New(fmt.Sprintf("Invalid tag: %s, clustering order is only allowed on clustering key", tagStr))
 		}
+		if slices.Contains(keys, "asc") && slices.Contains(keys, "desc") {
+			return errors.New(fmt.Sprintf("Invalid tag: %s, clustering order could not be both asc and desc", tagStr))
+		}
+		_, hasIndexName := cqlTagValue(tag, "index_name")
+		if hasIndexName && indexKind(tag) == "" {
+			return errors.New(fmt.Sprintf("Invalid tag: %s, index name is only allowed on indexed field", tagStr))
+		}
+		if indexKind(tag) != "" && (isPk || isCounterField(tag)) {
+			return errors.New(fmt.Sprintf("Invalid tag: %s, partition key and counter field could not be indexed", tagStr))
+		}
+	}
+	return nil
+}
+
+// Key words of the cql tag
+func cqlTagKeys(tag reflect.StructTag) []string {
+	tagStr := tag.Get(cqlTAG)
+	if tagStr == "" {
+		return nil
 	}
-	return true
+	return strings.Split(tagStr, ",")
+}
+
+func hasCqlTagKey(tag reflect.StructTag, key string) bool {
+	return slices.Contains(cqlTagKeys(tag), key)
+}
+
+// Value of a key=value key word of the cql tag, ok is false if the key word is missing
+func cqlTagValue(tag reflect.StructTag, key string) (string, bool) {
+	for _, keyword := range cqlTagKeys(tag) {
+		name, value, _ := strings.Cut(keyword, "=")
+		if name == key {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// Kind of the secondary index declared by the index key word, a bare index is a legacy index.
+// Empty if the field is not indexed.
+func indexKind(tag reflect.StructTag) string {
+	kind, ok := cqlTagValue(tag, "index")
+	if !ok {
+		return ""
+	}
+	if kind == "" {
+		return "legacy"
+	}
+	return kind
 }
 
 func isPartitionKey(tag reflect.StructTag) bool {
-	return strings.Contains(tag.Get(cqlTAG), "pk")
+	return hasCqlTagKey(tag, "pk")
 }
 
 func isClusterKey(tag reflect.StructTag) bool {
-	return strings.Contains(tag.Get(cqlTAG), "ck")
+	return hasCqlTagKey(tag, "ck")
 }
 
 func isStaticFiled(tag reflect.StructTag) bool {
-	return strings.Contains(tag.Get(cqlTAG), "static")
+	return hasCqlTagKey(tag, "static")
 }
 
 func isDateFiled(tag reflect.StructTag) bool {
-	return strings.Contains(tag.Get(cqlTAG), "date")
+	return hasCqlTagKey(tag, "date")
+}
+
+func isCounterField(tag reflect.StructTag) bool {
+	return hasCqlTagKey(tag, "counter")
+}
+
+func isDescendingField(tag reflect.StructTag) bool {
+	return hasCqlTagKey(tag, "desc")
+}
+
+// Bind values of all fields of a model object in field order, nil for nil pointers and ignored fields.
+// Generated accessors are used if a mapper was registered for the model.
+func modelValues(val reflect.Value, schema tableSchema) []interface{} {
+	if schema.mapper != nil {
+		return schema.mapper.values(val.Interface())
+	}
+	values := make([]interface{}, len(schema.fields))
+	for i, fieldName := range schema.fields {
+		if fieldName != "-" {
+			values[i] = convertToNormalValue(val.Field(i))
+		}
+	}
+	return values
+}
+
+// Scan destinations of the selected columns of a model object
+func fieldPointers[T interface{}](obj *T, selectFields []string, schema tableSchema) []interface{} {
+	if schema.mapper != nil {
+		return schema.mapper.pointers(obj, selectFields)
+	}
+	return getPointersOfStructElements(unsafe.Pointer(obj), selectFields, schema.fieldMap)
 }
 
 // Get pointers of struct elements for data scanning usage.
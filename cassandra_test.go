@@ -3,6 +3,7 @@ package nosqlorm
 import (
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"reflect"
 	"testing"
 )
 
@@ -98,3 +99,84 @@ func Test_GetFieldDBType(t *testing.T) {
 	}
 
 }
+
+type testPerson struct {
+	Name    string   `json:"name" cql:"pk"`
+	Age     int8     `json:"age" cql:"ck"`
+	Address *string  `json:"address"`
+	Income  *float64 `json:"-"`
+}
+
+type testPageView struct {
+	Page  string `json:"page" cql:"pk"`
+	Views int64  `json:"views" cql:"counter"`
+}
+
+func Test_BuildStatements(t *testing.T) {
+	_, err := NewCqlOrm[testPerson](nil)
+	assert.NoError(t, err)
+	_, err = NewCqlOrm[testPageView](nil)
+	assert.NoError(t, err)
+
+	person := testPerson{Name: "tony", Age: 30, Address: GetPointer("street")}
+	sql, values, err := buildInsertStatement(reflect.ValueOf(person), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO testperson (name,age,address) VALUES (?,?,?);", sql)
+	assert.Equal(t, []interface{}{"tony", int8(30), "street"}, values)
+
+	sql, values, err = buildUpdateStatement(reflect.ValueOf(person), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE testperson SET address=? WHERE name=? AND age=?;", sql)
+	assert.Equal(t, []interface{}{"street", "tony", int8(30)}, values)
+
+	sql, values, err = buildDeleteStatement(reflect.ValueOf(person), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM testperson WHERE name=? AND age=?;", sql)
+	assert.Equal(t, []interface{}{"tony", int8(30)}, values)
+
+	sql, values, err = buildUpdateStatement(reflect.ValueOf(testPageView{Page: "home", Views: 1}), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE testpageview SET views=views+? WHERE page=?;", sql)
+	assert.Equal(t, []interface{}{int64(1), "home"}, values)
+
+	_, _, err = buildInsertStatement(reflect.ValueOf(struct{}{}), statementOptions{})
+	assert.Error(t, err)
+}
+
+type testBadIndexTag struct {
+	Id    string `json:"id" cql:"pk"`
+	Email string `json:"email" cql:"index=foo"`
+}
+
+type testBadFieldType struct {
+	Id   string          `json:"id" cql:"pk"`
+	Tags map[string]bool `json:"tags"`
+}
+
+func Test_RegisterModels(t *testing.T) {
+	assert.NoError(t, RegisterModels(testPerson{}, testPageView{}))
+	assert.Error(t, RegisterModels("not a struct"))
+
+	// Invalid tags and field types are returned instead of exiting the process
+	assert.Error(t, RegisterModels(testBadIndexTag{}))
+	assert.Error(t, RegisterModels(testBadFieldType{}))
+	_, err := NewCqlOrm[testBadIndexTag](nil)
+	assert.Error(t, err)
+
+	sql, _, err := buildDeleteStatement(reflect.Indirect(reflect.ValueOf(&testPageView{Page: "home"})), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM testpageview WHERE page=?;", sql)
+}
+
+func Test_DDLLogger(t *testing.T) {
+	// Nothing is logged by default
+	logDDL("CREATE TABLE IF NOT EXISTS testperson (name text, PRIMARY KEY (name));")
+
+	logged := make([]string, 0)
+	SetDDLLogger(func(statement string) {
+		logged = append(logged, statement)
+	})
+	defer SetDDLLogger(nil)
+	logDDL("ALTER TABLE testperson ADD nickname text;")
+	assert.Equal(t, []string{"ALTER TABLE testperson ADD nickname text;"}, logged)
+}
@@ -0,0 +1,329 @@
+// Command nosqlorm-cql2go generates Go model structs from CREATE TABLE and CREATE TYPE statements of CQL schema files.
+//
+//	go run github.com/Tonyzhuwei/nosqlorm/cmd/nosqlorm-cql2go -package models -output models.go schema.cql
+//
+// Column types follow the type mapping of the ORM in reverse, and key columns get the same cql tags,
+// clustering keys declared DESC in CLUSTERING ORDER BY get the desc tag.
+// Columns which could not be mapped are reported and left out of the struct, tables whose keys could not be
+// mapped are left out entirely. User defined types become structs with gocql field tags.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Tonyzhuwei/nosqlorm"
+)
+
+var (
+	pkgName  = flag.String("package", "models", "package name of the generated file")
+	output   = flag.String("output", "", "output file, default is stdout")
+	nullable = flag.Bool("nullable", false, "map non-key scalar columns to pointers, so that NULL and unset values are nil")
+	strict   = flag.Bool("strict", false, "fail if any column could not be mapped")
+)
+
+// Reverse of the runtime type mapping
+var scalarTypes = map[string]string{
+	"boolean":   "bool",
+	"text":      "string",
+	"varchar":   "string",
+	"tinyint":   "int8",
+	"smallint":  "int16",
+	"int":       "int32",
+	"bigint":    "int64",
+	"float":     "float32",
+	"double":    "float64",
+	"timestamp": "time.Time",
+	"date":      "time.Time",
+	"counter":   "int64",
+}
+
+type goField struct {
+	name   string
+	goType string
+	tag    string
+}
+
+type goStruct struct {
+	name    string
+	comment string
+	// CQL statement the struct is generated from, such as table events
+	origin string
+	fields []goField
+	// Rendered as a TableName method if the lower-cased struct name is not the table name
+	tableName string
+}
+
+func main() {
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("nosqlorm-cql2go: ")
+	if flag.NArg() == 0 {
+		log.Fatal("usage: nosqlorm-cql2go [flags] schema.cql...")
+	}
+
+	var src strings.Builder
+	sources := make([]string, 0, flag.NArg())
+	for _, file := range flag.Args() {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		src.Write(content)
+		src.WriteString("\n;\n")
+		sources = append(sources, filepath.Base(file))
+	}
+
+	code, problems, err := generate(src.String(), strings.Join(sources, ", "), *pkgName, *nullable)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, problem := range problems {
+		log.Println(problem)
+	}
+	if *strict && len(problems) > 0 {
+		log.Fatal(fmt.Sprintf("%d columns could not be mapped", len(problems)))
+	}
+
+	if *output == "" {
+		os.Stdout.Write(code)
+		return
+	}
+	if err := os.WriteFile(*output, code, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Parse the schema and render the models, problems lists the columns which could not be mapped
+func generate(src string, source string, pkg string, nullable bool) ([]byte, []string, error) {
+	schema, err := parseSchema(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(schema.tables) == 0 && len(schema.types) == 0 {
+		return nil, nil, errors.New("no CREATE TABLE or CREATE TYPE statement found")
+	}
+
+	// User defined types are suffixed if a table has the same Go name, such as type address and table address
+	tableNames := make(map[string]bool, len(schema.tables))
+	for _, table := range schema.tables {
+		tableNames[goName(table.name)] = true
+	}
+	udts := make(map[string]string)
+	for _, typ := range schema.types {
+		name := goName(typ.name)
+		if tableNames[name] {
+			name += "Type"
+		}
+		udts[typ.name] = name
+	}
+
+	problems := make([]string, 0)
+	structs := make([]goStruct, 0, len(schema.types)+len(schema.tables))
+	for _, typ := range schema.types {
+		st, typeProblems := mapType(typ, udts)
+		problems = append(problems, typeProblems...)
+		structs = append(structs, st)
+	}
+	for _, table := range schema.tables {
+		st, tableProblems, ok := mapTable(table, nullable)
+		problems = append(problems, tableProblems...)
+		if ok {
+			structs = append(structs, st)
+		}
+	}
+	// Different names could still map to the same Go type, such as page_views and page__views
+	seen := make(map[string]string, len(structs))
+	for _, st := range structs {
+		if other, ok := seen[st.name]; ok {
+			return nil, nil, errors.New(fmt.Sprintf("duplicate Go type %s of %s and %s", st.name, other, st.origin))
+		}
+		seen[st.name] = st.origin
+	}
+	code, err := render(pkg, source, structs)
+	return code, problems, err
+}
+
+// Map columns of a table to fields with the ORM tags, ok is false if a primary key column could not be mapped
+func mapTable(table cqlTableDef, nullable bool) (goStruct, []string, bool) {
+	st := goStruct{name: goName(table.name), comment: fmt.Sprintf("%s maps table %s", goName(table.name), table.name), origin: "table " + table.name}
+	problems := make([]string, 0)
+	if strings.ToLower(st.name) != table.name {
+		st.tableName = table.name
+	}
+
+	for _, column := range table.columns {
+		isPk := slices.Contains(table.partitionKeys, column.name)
+		isCk := slices.Contains(table.clusteringKeys, column.name)
+		isDesc := slices.Contains(table.descendingKeys, column.name)
+		field, err := mapColumn(column, isPk, isCk, isDesc, nullable)
+		if err != nil {
+			if isPk || isCk {
+				problems = append(problems, fmt.Sprintf("table %s: skipped, key column %s: %s", table.name, column.name, err.Error()))
+				return goStruct{}, problems, false
+			}
+			problems = append(problems, fmt.Sprintf("table %s: column %s: %s", table.name, column.name, err.Error()))
+			continue
+		}
+		st.fields = append(st.fields, field)
+	}
+	return st, problems, true
+}
+
+func mapColumn(column cqlColumnDef, isPk bool, isCk bool, isDesc bool, nullable bool) (goField, error) {
+	if column.quoted && column.name != strings.ToLower(column.name) {
+		return goField{}, errors.New("case-sensitive column names are not supported")
+	}
+	typ, keys, err := goType(column.dataType)
+	if err != nil {
+		return goField{}, err
+	}
+	if isPk {
+		keys = append([]string{"pk"}, keys...)
+	}
+	if isCk && isDesc {
+		keys = append([]string{"ck", "desc"}, keys...)
+	} else if isCk {
+		keys = append([]string{"ck"}, keys...)
+	}
+	if column.static {
+		keys = append(keys, "static")
+	}
+	if nullable && !isPk && !isCk && !slices.Contains(keys, "counter") && !strings.HasPrefix(typ, "[]") {
+		typ = "*" + typ
+	}
+
+	tag := fmt.Sprintf(`json:"%s"`, column.name)
+	if len(keys) > 0 {
+		tag += fmt.Sprintf(` cql:"%s"`, strings.Join(keys, ","))
+	}
+
+	// The runtime path must map the field back to the same column type
+	mapped, err := nosqlorm.ParseCqlColumn(typ, reflect.StructTag(tag))
+	if err != nil {
+		return goField{}, err
+	}
+	if mapped.Type != canonicalType(column.dataType) {
+		return goField{}, errors.New(fmt.Sprintf("type %s would be mapped back to %s", column.dataType, mapped.Type))
+	}
+	return goField{name: goName(column.name), goType: typ, tag: tag}, nil
+}
+
+// Go type of a column type and the cql tag keys it needs
+func goType(typ cqlTypeExpr) (string, []string, error) {
+	if typ.name == "list" && len(typ.args) == 1 && len(typ.args[0].args) == 0 && typ.args[0].name != "counter" {
+		elem, keys, err := goType(typ.args[0])
+		if err != nil {
+			return "", nil, err
+		}
+		return "[]" + elem, keys, nil
+	}
+	goTyp, ok := scalarTypes[typ.name]
+	if !ok || len(typ.args) > 0 {
+		return "", nil, errors.New("unsupported type " + typ.String())
+	}
+	switch typ.name {
+	case "date":
+		return goTyp, []string{"date"}, nil
+	case "counter":
+		return goTyp, []string{"counter"}, nil
+	}
+	return goTyp, nil, nil
+}
+
+// Column type with aliases resolved, as the runtime type mapping renders it
+func canonicalType(typ cqlTypeExpr) string {
+	if typ.name == "varchar" {
+		typ.name = "text"
+	}
+	args := make([]cqlTypeExpr, 0, len(typ.args))
+	for _, arg := range typ.args {
+		args = append(args, cqlTypeExpr{name: canonicalType(arg)})
+	}
+	typ.args = args
+	return typ.String()
+}
+
+// Map fields of a user defined type, gocql matches them by the cql tag
+func mapType(typ cqlTypeDef, udts map[string]string) (goStruct, []string) {
+	st := goStruct{name: udts[typ.name], comment: fmt.Sprintf("%s maps user defined type %s", udts[typ.name], typ.name), origin: "type " + typ.name}
+	problems := make([]string, 0)
+	for _, field := range typ.fields {
+		fieldType, err := udtFieldType(field.dataType, udts)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("type %s: field %s: %s", typ.name, field.name, err.Error()))
+			continue
+		}
+		st.fields = append(st.fields, goField{
+			name:   goName(field.name),
+			goType: fieldType,
+			tag:    fmt.Sprintf(`cql:"%s"`, field.name),
+		})
+	}
+	return st, problems
+}
+
+func udtFieldType(typ cqlTypeExpr, udts map[string]string) (string, error) {
+	if typ.name == "frozen" && len(typ.args) == 1 {
+		return udtFieldType(typ.args[0], udts)
+	}
+	if name, ok := udts[typ.name]; ok && len(typ.args) == 0 {
+		return name, nil
+	}
+	if (typ.name == "list" || typ.name == "set") && len(typ.args) == 1 {
+		elem, err := udtFieldType(typ.args[0], udts)
+		return "[]" + elem, err
+	}
+	if goTyp, ok := scalarTypes[typ.name]; ok && typ.name != "counter" && len(typ.args) == 0 {
+		return goTyp, nil
+	}
+	return "", errors.New("unsupported type " + typ.String())
+}
+
+// Exported Go name of a CQL identifier, user_id becomes UserId
+func goName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var sb strings.Builder
+	for _, part := range parts {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	result := sb.String()
+	if result == "" || (result[0] >= '0' && result[0] <= '9') {
+		result = "X" + result
+	}
+	return result
+}
+
+func render(pkg string, source string, structs []goStruct) ([]byte, error) {
+	var body bytes.Buffer
+	for _, st := range structs {
+		fmt.Fprintf(&body, "\n// %s\ntype %s struct {\n", st.comment, st.name)
+		for _, field := range st.fields {
+			fmt.Fprintf(&body, "%s %s %s\n", field.name, field.goType, "`"+field.tag+"`")
+		}
+		body.WriteString("}\n")
+		if st.tableName != "" {
+			fmt.Fprintf(&body, "\nfunc (%s) TableName() string {\nreturn %q\n}\n", st.name, st.tableName)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by nosqlorm-cql2go from %s. DO NOT EDIT.\n\npackage %s\n", source, pkg)
+	if strings.Contains(body.String(), "time.Time") {
+		buf.WriteString("\nimport \"time\"\n")
+	}
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
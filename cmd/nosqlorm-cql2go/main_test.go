@@ -0,0 +1,93 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const testSchema = `
+-- keyspace statements are skipped
+CREATE KEYSPACE IF NOT EXISTS app WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1};
+
+CREATE TYPE IF NOT EXISTS app.address (street text, city varchar, ip inet);
+
+CREATE TABLE IF NOT EXISTS app.events (
+    user_id text,
+    day date,
+    ts timestamp,
+    kind text STATIC,
+    tags list<text>,
+    home frozen<address>,
+    PRIMARY KEY ((user_id, day), ts)
+) WITH CLUSTERING ORDER BY (ts DESC) AND comment = 'it''s';
+
+CREATE TABLE page_views (page text PRIMARY KEY, views counter);
+CREATE TABLE sessions (id uuid PRIMARY KEY, name text);
+`
+
+func Test_ParseSchema(t *testing.T) {
+	schema, err := parseSchema(testSchema)
+	assert.NoError(t, err)
+	assert.Len(t, schema.types, 1)
+	assert.Len(t, schema.tables, 3)
+
+	events := schema.tables[0]
+	assert.Equal(t, "events", events.name)
+	assert.Equal(t, []string{"user_id", "day"}, events.partitionKeys)
+	assert.Equal(t, []string{"ts"}, events.clusteringKeys)
+	assert.Equal(t, []string{"ts"}, events.descendingKeys)
+	assert.True(t, events.columns[3].static)
+	assert.Equal(t, "frozen<address>", events.columns[5].dataType.String())
+	assert.Equal(t, []string{"page"}, schema.tables[1].partitionKeys)
+
+	_, err = parseSchema("CREATE TABLE broken (id text, name text);")
+	assert.ErrorContains(t, err, "missing primary key")
+	_, err = parseSchema("CREATE TABLE broken (id text PRIMARY KEY, name list<text);")
+	assert.Error(t, err)
+}
+
+func Test_Generate(t *testing.T) {
+	code, problems, err := generate(testSchema, "schema.cql", "models", false)
+	assert.NoError(t, err)
+	out := string(code)
+	assert.Contains(t, out, "// Code generated by nosqlorm-cql2go from schema.cql. DO NOT EDIT.")
+	assert.Contains(t, out, "package models")
+	assert.Contains(t, out, `import "time"`)
+	assert.Contains(t, out, "type Address struct {\n\tStreet string `cql:\"street\"`\n\tCity   string `cql:\"city\"`\n}")
+	assert.Contains(t, out, "UserId string    `json:\"user_id\" cql:\"pk\"`")
+	assert.Contains(t, out, "Day    time.Time `json:\"day\" cql:\"pk,date\"`")
+	assert.Contains(t, out, "Ts     time.Time `json:\"ts\" cql:\"ck,desc\"`")
+	assert.Contains(t, out, "Kind   string    `json:\"kind\" cql:\"static\"`")
+	assert.Contains(t, out, "Tags   []string  `json:\"tags\"`")
+	assert.Contains(t, out, "Views int64  `json:\"views\" cql:\"counter\"`")
+	assert.NotContains(t, out, "Sessions")
+	assert.Contains(t, out, "func (PageViews) TableName() string {\n\treturn \"page_views\"\n}")
+	assert.NotContains(t, out, "func (Events) TableName()")
+	assert.Equal(t, []string{
+		"type address: field ip: unsupported type inet",
+		"table events: column home: unsupported type frozen<address>",
+		"table sessions: skipped, key column id: unsupported type uuid",
+	}, problems)
+
+	code, _, err = generate(testSchema, "schema.cql", "models", true)
+	assert.NoError(t, err)
+	assert.Contains(t, string(code), "Kind   *string   `json:\"kind\" cql:\"static\"`")
+	assert.Contains(t, string(code), "Tags   []string  `json:\"tags\"`")
+}
+
+func Test_GenerateNameClash(t *testing.T) {
+	// A type with the Go name of a table is suffixed
+	code, _, err := generate("CREATE TYPE address (street text);\nCREATE TABLE address (id text PRIMARY KEY, home frozen<address>);", "schema.cql", "models", false)
+	assert.NoError(t, err)
+	assert.Contains(t, string(code), "type AddressType struct {")
+	assert.Contains(t, string(code), "type Address struct {")
+
+	_, _, err = generate("CREATE TABLE page_views (id text PRIMARY KEY);\nCREATE TABLE page__views (id text PRIMARY KEY);", "schema.cql", "models", false)
+	assert.EqualError(t, err, "duplicate Go type PageViews of table page_views and table page__views")
+}
+
+func Test_GoName(t *testing.T) {
+	assert.Equal(t, "UserId", goName("user_id"))
+	assert.Equal(t, "Pageviews", goName("pageviews"))
+	assert.Equal(t, "X2fa", goName("2fa"))
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type cqlToken struct {
+	text   string
+	quoted bool
+}
+
+// Type expression such as list<text> or frozen<address>
+type cqlTypeExpr struct {
+	name string
+	args []cqlTypeExpr
+}
+
+func (t cqlTypeExpr) String() string {
+	if len(t.args) == 0 {
+		return t.name
+	}
+	args := make([]string, 0, len(t.args))
+	for _, arg := range t.args {
+		args = append(args, arg.String())
+	}
+	return fmt.Sprintf("%s<%s>", t.name, strings.Join(args, ", "))
+}
+
+type cqlColumnDef struct {
+	name     string
+	quoted   bool
+	dataType cqlTypeExpr
+	static   bool
+}
+
+type cqlTableDef struct {
+	name           string
+	columns        []cqlColumnDef
+	partitionKeys  []string
+	clusteringKeys []string
+	// Clustering keys declared DESC in CLUSTERING ORDER BY
+	descendingKeys []string
+}
+
+type cqlTypeDef struct {
+	name   string
+	fields []cqlColumnDef
+}
+
+type cqlSchema struct {
+	tables []cqlTableDef
+	types  []cqlTypeDef
+}
+
+// Split CQL source into identifiers, literals and symbols, comments are dropped.
+// Unquoted identifiers are case-insensitive in CQL, so they are lower-cased.
+func tokenize(src string) ([]cqlToken, error) {
+	tokens := make([]cqlToken, 0)
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "--") || strings.HasPrefix(src[i:], "//"):
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				return tokens, nil
+			}
+			i += end + 1
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, errors.New("unterminated comment")
+			}
+			i += end + 4
+		case c == '"' || c == '\'':
+			text, n, err := readQuoted(src[i:], c)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, cqlToken{text: text, quoted: c == '"'})
+			i += n
+		case isIdentChar(c):
+			start := i
+			for i < len(src) && isIdentChar(src[i]) {
+				i++
+			}
+			tokens = append(tokens, cqlToken{text: strings.ToLower(src[start:i])})
+		default:
+			tokens = append(tokens, cqlToken{text: string(c)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// Read a quoted identifier or string literal, a doubled quote escapes the quote itself
+func readQuoted(src string, quote byte) (string, int, error) {
+	var sb strings.Builder
+	for i := 1; i < len(src); i++ {
+		if src[i] != quote {
+			sb.WriteByte(src[i])
+			continue
+		}
+		if i+1 < len(src) && src[i+1] == quote {
+			sb.WriteByte(quote)
+			i++
+			continue
+		}
+		return sb.String(), i + 1, nil
+	}
+	return "", 0, errors.New("unterminated quote: " + src)
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// Parse CREATE TABLE and CREATE TYPE statements, other statements are skipped
+func parseSchema(src string) (cqlSchema, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return cqlSchema{}, err
+	}
+
+	schema := cqlSchema{}
+	for _, stmt := range splitStatements(tokens) {
+		p := &cqlParser{tokens: stmt}
+		switch {
+		case p.accept("create", "table") || p.accept("create", "columnfamily"):
+			table, err := p.parseTable()
+			if err != nil {
+				return cqlSchema{}, err
+			}
+			schema.tables = append(schema.tables, table)
+		case p.accept("create", "type"):
+			typ, err := p.parseType()
+			if err != nil {
+				return cqlSchema{}, err
+			}
+			schema.types = append(schema.types, typ)
+		}
+	}
+	return schema, nil
+}
+
+func splitStatements(tokens []cqlToken) [][]cqlToken {
+	statements := make([][]cqlToken, 0)
+	start := 0
+	for i, tok := range tokens {
+		if !tok.quoted && tok.text == ";" {
+			if i > start {
+				statements = append(statements, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		statements = append(statements, tokens[start:])
+	}
+	return statements
+}
+
+type cqlParser struct {
+	tokens []cqlToken
+	pos    int
+}
+
+func (p *cqlParser) peek() cqlToken {
+	if p.pos >= len(p.tokens) {
+		return cqlToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *cqlParser) next() cqlToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// Consume the keywords if the next tokens are them
+func (p *cqlParser) accept(words ...string) bool {
+	for i, word := range words {
+		if p.pos+i >= len(p.tokens) {
+			return false
+		}
+		tok := p.tokens[p.pos+i]
+		if tok.quoted || tok.text != word {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *cqlParser) expect(word string) error {
+	if !p.accept(word) {
+		return errors.New(fmt.Sprintf("expected %s but got %s", word, p.describe()))
+	}
+	return nil
+}
+
+func (p *cqlParser) describe() string {
+	if p.pos >= len(p.tokens) {
+		return "end of statement"
+	}
+	return "\"" + p.peek().text + "\""
+}
+
+func (p *cqlParser) identifier() (cqlToken, error) {
+	tok := p.next()
+	if tok.text == "" || (!tok.quoted && !isIdentChar(tok.text[0])) {
+		p.pos--
+		return cqlToken{}, errors.New("expected identifier but got " + p.describe())
+	}
+	return tok, nil
+}
+
+// Table or type name, the keyspace qualifier is dropped
+func (p *cqlParser) qualifiedName() (string, error) {
+	p.accept("if", "not", "exists")
+	name, err := p.identifier()
+	if err != nil {
+		return "", err
+	}
+	if p.accept(".") {
+		if name, err = p.identifier(); err != nil {
+			return "", err
+		}
+	}
+	return name.text, nil
+}
+
+func (p *cqlParser) parseTable() (cqlTableDef, error) {
+	name, err := p.qualifiedName()
+	if err != nil {
+		return cqlTableDef{}, err
+	}
+	table := cqlTableDef{name: name}
+	if err := p.expect("("); err != nil {
+		return cqlTableDef{}, errors.New(fmt.Sprintf("Table %s: %s", name, err.Error()))
+	}
+	for {
+		if p.accept("primary", "key") {
+			if err := p.parsePrimaryKey(&table); err != nil {
+				return cqlTableDef{}, errors.New(fmt.Sprintf("Table %s: %s", name, err.Error()))
+			}
+		} else {
+			column, err := p.parseColumn()
+			if err != nil {
+				return cqlTableDef{}, errors.New(fmt.Sprintf("Table %s: %s", name, err.Error()))
+			}
+			if p.accept("static") {
+				column.static = true
+			}
+			if p.accept("primary", "key") {
+				table.partitionKeys = append(table.partitionKeys, column.name)
+			}
+			table.columns = append(table.columns, column)
+		}
+		if p.accept(")") {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return cqlTableDef{}, errors.New(fmt.Sprintf("Table %s: %s", name, err.Error()))
+		}
+	}
+	if len(table.partitionKeys) == 0 {
+		return cqlTableDef{}, errors.New(fmt.Sprintf("Table %s: missing primary key", name))
+	}
+	if p.accept("with") {
+		if err := p.parseClusteringOrder(&table); err != nil {
+			return cqlTableDef{}, errors.New(fmt.Sprintf("Table %s: %s", name, err.Error()))
+		}
+	}
+	return table, nil
+}
+
+// Find CLUSTERING ORDER BY (ck ASC|DESC, ...) among the table options, other options are skipped
+func (p *cqlParser) parseClusteringOrder(table *cqlTableDef) error {
+	for p.pos < len(p.tokens) {
+		if !p.accept("clustering", "order", "by") {
+			p.next()
+			continue
+		}
+		if err := p.expect("("); err != nil {
+			return err
+		}
+		for {
+			key, err := p.identifier()
+			if err != nil {
+				return err
+			}
+			if p.accept("desc") {
+				table.descendingKeys = append(table.descendingKeys, key.text)
+			} else {
+				p.accept("asc")
+			}
+			if p.accept(")") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PRIMARY KEY (pk, ck...) or PRIMARY KEY ((pk1, pk2), ck...)
+func (p *cqlParser) parsePrimaryKey(table *cqlTableDef) error {
+	if err := p.expect("("); err != nil {
+		return err
+	}
+	if p.accept("(") {
+		for {
+			key, err := p.identifier()
+			if err != nil {
+				return err
+			}
+			table.partitionKeys = append(table.partitionKeys, key.text)
+			if p.accept(")") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return err
+			}
+		}
+	} else {
+		key, err := p.identifier()
+		if err != nil {
+			return err
+		}
+		table.partitionKeys = append(table.partitionKeys, key.text)
+	}
+	for p.accept(",") {
+		key, err := p.identifier()
+		if err != nil {
+			return err
+		}
+		table.clusteringKeys = append(table.clusteringKeys, key.text)
+	}
+	return p.expect(")")
+}
+
+func (p *cqlParser) parseType() (cqlTypeDef, error) {
+	name, err := p.qualifiedName()
+	if err != nil {
+		return cqlTypeDef{}, err
+	}
+	typ := cqlTypeDef{name: name}
+	if err := p.expect("("); err != nil {
+		return cqlTypeDef{}, errors.New(fmt.Sprintf("Type %s: %s", name, err.Error()))
+	}
+	for {
+		field, err := p.parseColumn()
+		if err != nil {
+			return cqlTypeDef{}, errors.New(fmt.Sprintf("Type %s: %s", name, err.Error()))
+		}
+		typ.fields = append(typ.fields, field)
+		if p.accept(")") {
+			return typ, nil
+		}
+		if err := p.expect(","); err != nil {
+			return cqlTypeDef{}, errors.New(fmt.Sprintf("Type %s: %s", name, err.Error()))
+		}
+	}
+}
+
+func (p *cqlParser) parseColumn() (cqlColumnDef, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return cqlColumnDef{}, err
+	}
+	dataType, err := p.parseTypeExpr()
+	if err != nil {
+		return cqlColumnDef{}, errors.New(fmt.Sprintf("column %s: %s", name.text, err.Error()))
+	}
+	return cqlColumnDef{name: name.text, quoted: name.quoted, dataType: dataType}, nil
+}
+
+func (p *cqlParser) parseTypeExpr() (cqlTypeExpr, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return cqlTypeExpr{}, err
+	}
+	typ := cqlTypeExpr{name: name.text}
+	if !p.accept("<") {
+		return typ, nil
+	}
+	for {
+		arg, err := p.parseTypeExpr()
+		if err != nil {
+			return cqlTypeExpr{}, err
+		}
+		typ.args = append(typ.args, arg)
+		if p.accept(">") {
+			return typ, nil
+		}
+		if err := p.expect(","); err != nil {
+			return cqlTypeExpr{}, err
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// Command nosqlorm-gen generates reflection-free mappers of json/cql tagged model structs.
+//
+// Add a go:generate directive next to the models and run go generate:
+//
+//	//go:generate go run github.com/Tonyzhuwei/nosqlorm/cmd/nosqlorm-gen -type Person
+//
+// The generated file registers typed bind and scan functions and column lists by nosqlorm.RegisterMapper,
+// so NewCqlOrm uses them instead of reflection. Models without generated code keep using the reflection path.
+// A <Model>DDL function per model renders its CREATE statements.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Tonyzhuwei/nosqlorm"
+)
+
+const generatedHeader = "// Code generated by nosqlorm-gen. DO NOT EDIT."
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of model names, default is all structs with a partition key")
+	output    = flag.String("output", "nosqlorm_mappers.go", "output file name, relative to the package directory")
+)
+
+type modelField struct {
+	goName    string
+	isPointer bool
+	column    nosqlorm.CqlColumn
+}
+
+type model struct {
+	name   string
+	fields []modelField
+}
+
+func main() {
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	src, err := generate(dir, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, *output), src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Parse the models of the package in dir and render the mapper file
+func generate(dir string, names []string) ([]byte, error) {
+	pkgName, structs, err := parsePackage(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 {
+		for name, st := range structs {
+			if hasPartitionKey(st) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("no model found in " + dir)
+	}
+
+	models := make([]model, 0, len(names))
+	for _, name := range names {
+		st, ok := structs[strings.TrimSpace(name)]
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Model %s is not a struct of package %s", name, pkgName))
+		}
+		m, err := parseModel(strings.TrimSpace(name), st)
+		if err != nil {
+			return nil, err
+		}
+		models = append(models, m)
+	}
+	return render(pkgName, models)
+}
+
+// Parse non-test, non-generated Go files of a directory and collect its struct types
+func parsePackage(dir string) (string, map[string]*ast.StructType, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	pkgName := ""
+	structs := make(map[string]*ast.StructType)
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+		if err != nil {
+			return "", nil, err
+		}
+		if isGenerated(f) {
+			continue
+		}
+		pkgName = f.Name.Name
+		for _, decl := range f.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				if st, ok := typeSpec.Type.(*ast.StructType); ok && typeSpec.TypeParams == nil {
+					structs[typeSpec.Name.Name] = st
+				}
+			}
+		}
+	}
+	if pkgName == "" {
+		return "", nil, errors.New("no Go files found in " + dir)
+	}
+	return pkgName, structs, nil
+}
+
+// Only files written by nosqlorm-gen are skipped, models generated by other tools such as nosqlorm-cql2go are parsed
+func isGenerated(f *ast.File) bool {
+	for _, group := range f.Comments {
+		if group.Pos() > f.Package {
+			return false
+		}
+		for _, comment := range group.List {
+			if comment.Text == generatedHeader {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
+
+func hasPartitionKey(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		for _, key := range strings.Split(fieldTag(field).Get("cql"), ",") {
+			if key == "pk" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Map struct fields to columns with the same tag semantics and type mapping as the runtime path
+func parseModel(name string, st *ast.StructType) (model, error) {
+	m := model{name: name}
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			return model{}, errors.New(fmt.Sprintf("Model %s: embedded field %s is not supported", name, types.ExprString(field.Type)))
+		}
+		typeName := types.ExprString(field.Type)
+		for _, ident := range field.Names {
+			column, err := nosqlorm.ParseCqlColumn(typeName, fieldTag(field))
+			if err != nil {
+				return model{}, errors.New(fmt.Sprintf("Model %s, field %s: %s", name, ident.Name, err.Error()))
+			}
+			if column.Name != "-" && !ident.IsExported() {
+				return model{}, errors.New(fmt.Sprintf("Model %s, field %s: mapped field must be exported", name, ident.Name))
+			}
+			m.fields = append(m.fields, modelField{
+				goName:    ident.Name,
+				isPointer: strings.HasPrefix(typeName, "*"),
+				column:    column,
+			})
+		}
+	}
+
+	for _, field := range m.fields {
+		if field.column.PartitionKey {
+			return m, nil
+		}
+	}
+	return model{}, errors.New(fmt.Sprintf("Model %s must have at least one partition key", name))
+}
+
+func render(pkgName string, models []model) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n\npackage %s\n\nimport \"github.com/Tonyzhuwei/nosqlorm\"\n\nfunc init() {\n", generatedHeader, pkgName)
+	for _, m := range models {
+		columns := make([]string, 0, len(m.fields))
+		for _, field := range m.fields {
+			columns = append(columns, strconv.Quote(field.column.Name))
+		}
+		fmt.Fprintf(&buf, "nosqlorm.RegisterMapper(nosqlorm.ModelMapper[%s]{\n", m.name)
+		fmt.Fprintf(&buf, "Columns: []string{%s},\n", strings.Join(columns, ", "))
+
+		// Bind values follow convertToNormalValue: nil pointers are nil, others are dereferenced
+		fmt.Fprintf(&buf, "Values: func(obj %s) []interface{} {\nvalues := make([]interface{}, %d)\n", m.name, len(m.fields))
+		for i, field := range m.fields {
+			switch {
+			case field.column.Name == "-":
+			case field.isPointer:
+				fmt.Fprintf(&buf, "if obj.%s != nil {\nvalues[%d] = *obj.%s\n}\n", field.goName, i, field.goName)
+			default:
+				fmt.Fprintf(&buf, "values[%d] = obj.%s\n", i, field.goName)
+			}
+		}
+		buf.WriteString("return values\n},\n")
+
+		fmt.Fprintf(&buf, "Pointers: func(obj *%s, columns []string) []interface{} {\n", m.name)
+		buf.WriteString("pointers := make([]interface{}, 0, len(columns))\nfor _, column := range columns {\nswitch column {\n")
+		for _, field := range m.fields {
+			if field.column.Name == "-" {
+				continue
+			}
+			fmt.Fprintf(&buf, "case %s:\npointers = append(pointers, &obj.%s)\n", strconv.Quote(field.column.Name), field.goName)
+		}
+		buf.WriteString("}\n}\nreturn pointers\n},\n})\n")
+	}
+	buf.WriteString("}\n")
+
+	// DDL is rendered from the table definition of the runtime path, so it follows TableName, TableOptions and views
+	for _, m := range models {
+		fmt.Fprintf(&buf, "\n// %sDDL Render the CREATE statements of %s, the same DDL CreateCassandraTables runs.\n", m.name, m.name)
+		fmt.Fprintf(&buf, "func %sDDL() (string, error) {\nreturn nosqlorm.GenerateDDL(%s{})\n}\n", m.name, m.name)
+	}
+	return format.Source(buf.Bytes())
+}
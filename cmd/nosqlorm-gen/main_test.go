@@ -0,0 +1,92 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+const testModels = `package models
+
+import "time"
+
+type Event struct {
+	UserId  string     ` + "`json:\"user_id\" cql:\"pk\"`" + `
+	Ts      time.Time  ` + "`json:\"ts\" cql:\"ck\"`" + `
+	Kind    *string    ` + "`json:\"kind\"`" + `
+	Day     time.Time  ` + "`json:\"day\" cql:\"date\"`" + `
+	Scratch int        ` + "`json:\"-\"`" + `
+}
+
+type Options struct {
+	Verbose bool
+}
+
+type UserEvent struct {
+	UserId string ` + "`json:\"user_id\" cql:\"pk\"`" + `
+}
+
+func (*UserEvent) TableName() string {
+	return "user_events"
+}
+`
+
+func writeModels(t *testing.T, src string) string {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644))
+	return dir
+}
+
+func Test_Generate(t *testing.T) {
+	dir := writeModels(t, testModels)
+	src, err := generate(dir, nil)
+	assert.NoError(t, err)
+	out := string(src)
+	assert.Contains(t, out, "// Code generated by nosqlorm-gen. DO NOT EDIT.")
+	assert.Contains(t, out, "package models")
+	assert.Contains(t, out, "nosqlorm.ModelMapper[Event]{")
+	assert.NotContains(t, out, "ModelMapper[Options]")
+	assert.Contains(t, out, `Columns: []string{"user_id", "ts", "kind", "day", "-"},`)
+	assert.Contains(t, out, "func EventDDL() (string, error) {\n\treturn nosqlorm.GenerateDDL(Event{})\n}")
+	assert.Contains(t, out, "if obj.Kind != nil {\n\t\t\t\tvalues[2] = *obj.Kind\n\t\t\t}")
+	assert.Contains(t, out, "case \"ts\":\n\t\t\t\t\tpointers = append(pointers, &obj.Ts)")
+	assert.NotContains(t, out, "obj.Scratch")
+	assert.Contains(t, out, "nosqlorm.ModelMapper[UserEvent]{")
+
+	// Generated files are skipped when parsing the package again
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "nosqlorm_mappers.go"), src, 0644))
+	again, err := generate(dir, []string{"Event", "UserEvent"})
+	assert.NoError(t, err)
+	assert.Equal(t, src, again)
+}
+
+func Test_GenerateErrors(t *testing.T) {
+	dir := writeModels(t, testModels)
+	_, err := generate(dir, []string{"Missing"})
+	assert.Error(t, err)
+	_, err = generate(dir, []string{"Options"})
+	assert.Error(t, err)
+
+	dir = writeModels(t, "package models\n\ntype Bad struct {\n\tId uint8 `json:\"id\" cql:\"pk,foo\"`\n}\n")
+	_, err = generate(dir, []string{"Bad"})
+	assert.ErrorContains(t, err, "Bad, field Id")
+}
+
+func Test_GenerateFromCql2go(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "schema.cql")
+	assert.NoError(t, os.WriteFile(schema, []byte("CREATE TABLE user_events (user_id text, ts timestamp, kind text, PRIMARY KEY (user_id, ts));"), 0644))
+	out, err := exec.Command("go", "run", "../nosqlorm-cql2go", "-package", "models", "-output", filepath.Join(dir, "models.go"), schema).CombinedOutput()
+	assert.NoError(t, err, string(out))
+
+	// Models reverse-engineered by nosqlorm-cql2go are marked as generated, but not by nosqlorm-gen
+	pkgName, structs, err := parsePackage(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "models", pkgName)
+	assert.Contains(t, structs, "UserEvents")
+	src, err := generate(dir, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "nosqlorm.ModelMapper[UserEvents]{")
+}
@@ -0,0 +1,71 @@
+package nosqlorm
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Condition is a comparison between a column and a value, used by LWT IF clauses.
+type Condition struct {
+	Column string
+	Op     string
+	Value  interface{}
+}
+
+func Eq(column string, value interface{}) Condition {
+	return Condition{Column: column, Op: "=", Value: value}
+}
+
+func Ne(column string, value interface{}) Condition {
+	return Condition{Column: column, Op: "!=", Value: value}
+}
+
+func Gt(column string, value interface{}) Condition {
+	return Condition{Column: column, Op: ">", Value: value}
+}
+
+func Gte(column string, value interface{}) Condition {
+	return Condition{Column: column, Op: ">=", Value: value}
+}
+
+func Lt(column string, value interface{}) Condition {
+	return Condition{Column: column, Op: "<", Value: value}
+}
+
+func Lte(column string, value interface{}) Condition {
+	return Condition{Column: column, Op: "<=", Value: value}
+}
+
+// In Match any of the given values, values are bound as one list parameter.
+func In(column string, values ...interface{}) Condition {
+	return Condition{Column: column, Op: "IN", Value: values}
+}
+
+var allowedConditionOps = []string{"=", "!=", ">", ">=", "<", "<=", "IN"}
+
+// Render conditions to CQL, columns are validated against the table schema.
+func renderConditions(schema tableSchema, conditions []Condition) ([]string, []interface{}, error) {
+	clauses := make([]string, 0, len(conditions))
+	values := make([]interface{}, 0, len(conditions))
+	for _, cond := range conditions {
+		if _, ok := schema.fieldMap[cond.Column]; !ok || cond.Column == "-" {
+			return nil, nil, errors.New(fmt.Sprintf("Invalid condition: column %s not found", cond.Column))
+		}
+		op := strings.ToUpper(cond.Op)
+		if !slices.Contains(allowedConditionOps, op) {
+			return nil, nil, errors.New(fmt.Sprintf("Invalid condition: operator %s is not allowed", cond.Op))
+		}
+		clauses = append(clauses, fmt.Sprintf("%s%s?", cond.Column, renderOperator(op)))
+		values = append(values, cond.Value)
+	}
+	return clauses, values, nil
+}
+
+func renderOperator(op string) string {
+	if op == "IN" {
+		return " IN "
+	}
+	return op
+}
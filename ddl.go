@@ -0,0 +1,357 @@
+package nosqlorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ModelTableName could be implemented by models whose table name is not the lower-cased type name
+type ModelTableName interface {
+	TableName() string
+}
+
+// ModelTableOptions could be implemented by models to declare table options in their CREATE TABLE statement
+type ModelTableOptions interface {
+	TableOptions() TableOptions
+}
+
+// TableOptions Options rendered into the WITH clause of CREATE TABLE, zero values are left out
+type TableOptions struct {
+	// Clustering order of all clustering keys in key order, overrides asc and desc tags of the fields
+	ClusteringOrder []Ordering
+	// Compaction sub-options such as {"class": "TimeWindowCompactionStrategy", "compaction_window_unit": "DAYS"}
+	Compaction     map[string]string
+	GcGraceSeconds *int
+	// Default TTL of written rows, must be a whole number of seconds
+	DefaultTTL time.Duration
+	Comment    string
+}
+
+// Table definition of a model, shared by CreateCassandraTables and GenerateDDL
+type tableDefinition struct {
+	// Keyspace qualifying the table in statements, empty means the keyspace of the session
+	keyspace string
+	name     string
+	columns  []CqlColumn
+	options  TableOptions
+	// Base table of a materialized view, nil for tables
+	base *tableDefinition
+}
+
+// Table name used in statements
+func (def tableDefinition) qualifiedName() string {
+	if def.keyspace == "" {
+		return def.name
+	}
+	return def.keyspace + "." + def.name
+}
+
+func modelTableDefinition(model interface{}) (tableDefinition, error) {
+	typ := reflect.TypeOf(model)
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return tableDefinition{}, errors.New(fmt.Sprintf("Invalid model %v: must be struct", model))
+	}
+	return typeTableDefinition(typ)
+}
+
+func typeTableDefinition(typ reflect.Type) (tableDefinition, error) {
+	columns, err := modelColumns(typ)
+	if err != nil {
+		return tableDefinition{}, err
+	}
+	def := tableDefinition{name: modelTableName(typ), columns: columns}
+	if !identifierPattern.MatchString(def.name) {
+		return tableDefinition{}, errors.New(fmt.Sprintf("Invalid table name %s", def.name))
+	}
+	if model, ok := reflect.New(typ).Interface().(ModelTableOptions); ok {
+		def.options = model.TableOptions()
+	}
+	if _, err := ttlSeconds(def.options.DefaultTTL); err != nil {
+		return tableDefinition{}, errors.New(fmt.Sprintf("Invalid table options of %s: %s", def.name, err.Error()))
+	}
+	if err := applyClusteringOrder(def.name, def.columns, def.options.ClusteringOrder); err != nil {
+		return tableDefinition{}, err
+	}
+	if model, ok := reflect.New(typ).Interface().(ModelView); ok {
+		base, err := viewBaseDefinition(def, model.BaseModel())
+		if err != nil {
+			return tableDefinition{}, err
+		}
+		def.base = &base
+	}
+	return def, nil
+}
+
+// Set the clustering order of the table options to the columns, it must list the clustering keys in key order
+func applyClusteringOrder(tableName string, columns []CqlColumn, order []Ordering) error {
+	if len(order) == 0 {
+		return nil
+	}
+	keys := keyColumns(columns, "clustering")
+	if len(order) != len(keys) {
+		return errors.New(fmt.Sprintf("Invalid clustering order of %s: must list clustering keys %s", tableName, strings.Join(keys, ", ")))
+	}
+	for i, ordering := range order {
+		if ordering.Column != keys[i] {
+			return errors.New(fmt.Sprintf("Invalid clustering order of %s: must list clustering keys %s", tableName, strings.Join(keys, ", ")))
+		}
+	}
+	for i := range columns {
+		for _, ordering := range order {
+			if ordering.Column == columns[i].Name {
+				columns[i].Descending = ordering.Desc
+			}
+		}
+	}
+	return nil
+}
+
+// Statements creating the table and its indexes, or the materialized view
+func (def tableDefinition) createStatements() ([]string, error) {
+	if def.base != nil {
+		sql, err := createViewStatement(def)
+		if err != nil {
+			return nil, err
+		}
+		return []string{sql}, nil
+	}
+	sql, err := createTableStatement(def.qualifiedName(), def.columns, def.options)
+	if err != nil {
+		return nil, err
+	}
+	statements := []string{sql}
+	for _, column := range def.columns {
+		if column.Name != "-" && column.Index != "" {
+			statements = append(statements, def.createIndexStatement(column))
+		}
+	}
+	return statements, nil
+}
+
+// Table definition of the table the ORM object is bound to
+func (ctx *cqlOrm[T]) tableDefinition() (tableDefinition, error) {
+	var t T
+	def, err := typeTableDefinition(reflect.TypeOf(t))
+	if err != nil {
+		return tableDefinition{}, err
+	}
+	def.name = ctx.tableName()
+	def.keyspace = ctx.opts.keyspace
+	if ctx.opts.baseTableName != "" {
+		base := *def.base
+		base.name = ctx.opts.baseTableName
+		def.base = &base
+	}
+	return def, nil
+}
+
+// GenerateDDL Render the DDL of the table the ORM object is bound to, see WithTableName.
+func (ctx *cqlOrm[T]) GenerateDDL() (string, error) {
+	def, err := ctx.tableDefinition()
+	if err != nil {
+		return "", err
+	}
+	statements, err := def.createStatements()
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(statements, "\n") + "\n", nil
+}
+
+// CreateTable Create or update the table the ORM object is bound to, the same way as CreateCassandraTables.
+func (ctx *cqlOrm[T]) CreateTable() error {
+	def, err := ctx.tableDefinition()
+	if err != nil {
+		return err
+	}
+	return createCassandraTable(ctx.sess, def)
+}
+
+// GenerateDDL Render the CQL script creating the tables of models without a session, for review or for DBAs.
+// It is the same DDL CreateCassandraTables runs for missing tables.
+func GenerateDDL(models ...interface{}) (string, error) {
+	return GenerateDDLInKeyspace("", models...)
+}
+
+// GenerateDDLInKeyspace is the same as GenerateDDL, table names are qualified by the keyspace if it is not empty.
+func GenerateDDLInKeyspace(keyspace string, models ...interface{}) (string, error) {
+	if keyspace != "" {
+		if err := validateKeyspaceName(keyspace); err != nil {
+			return "", err
+		}
+	}
+	statements := make([]string, 0, len(models))
+	for _, model := range models {
+		def, err := modelTableDefinition(model)
+		if err != nil {
+			return "", err
+		}
+		def.keyspace = strings.ToLower(keyspace)
+		tableStatements, err := def.createStatements()
+		if err != nil {
+			return "", err
+		}
+		statements = append(statements, tableStatements...)
+	}
+	return strings.Join(statements, "\n") + "\n", nil
+}
+
+// Column definitions of all fields of a model type
+func modelColumns(typ reflect.Type) ([]CqlColumn, error) {
+	columns := make([]CqlColumn, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		column, err := ParseCqlColumn(typ.Field(i).Type.String(), typ.Field(i).Tag)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// CqlColumn Column definition of a struct field, "-" named columns are ignored fields without a type.
+type CqlColumn struct {
+	Name          string
+	Type          string
+	PartitionKey  bool
+	ClusteringKey bool
+	Static        bool
+	// Clustering order of a clustering key
+	Descending bool
+	// Kind of the secondary index on the column: sai, sasi or legacy, empty if not indexed
+	Index string
+	// Name of the index, empty means the Cassandra default <table>_<column>_idx
+	IndexName string
+}
+
+// ParseCqlColumn Parse the tags and the Go type name such as "*time.Time" of a struct field,
+// it is exported for code generators to share the tag semantics of the runtime path.
+func ParseCqlColumn(typeName string, tag reflect.StructTag) (CqlColumn, error) {
+	column := CqlColumn{Name: getFieldName(tag)}
+	if column.Name == "" {
+		return CqlColumn{}, errors.New("invalid CQL Tag: must have a json name")
+	}
+	if err := validateCqlTag(tag); err != nil {
+		return CqlColumn{}, err
+	}
+	if column.Name == "-" {
+		return column, nil
+	}
+	dbType, _, err := getTaggedDBType(typeName, tag)
+	if err != nil {
+		return CqlColumn{}, err
+	}
+	column.Type = dbType
+	column.PartitionKey = isPartitionKey(tag)
+	column.ClusteringKey = isClusterKey(tag)
+	column.Static = isStaticFiled(tag)
+	column.Descending = isDescendingField(tag)
+	column.Index = indexKind(tag)
+	indexName, _ := cqlTagValue(tag, "index_name")
+	column.IndexName = strings.ToLower(indexName)
+	return column, nil
+}
+
+// Render the CREATE TABLE statement of a table, ignored columns are skipped
+func createTableStatement(tableName string, columns []CqlColumn, options TableOptions) (string, error) {
+	fields := make([]string, 0)
+	for _, column := range columns {
+		if column.Name == "-" {
+			continue
+		}
+		isStatic := ""
+		if column.Static {
+			isStatic = " static"
+		}
+		fields = append(fields, fmt.Sprintf("%s %s%s", column.Name, column.Type, isStatic))
+	}
+	primaryKey, err := primaryKeyClause(tableName, columns)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s, PRIMARY KEY %s)%s;", tableName, strings.Join(fields, ", "), primaryKey, renderTableOptions(columns, options)), nil
+}
+
+// Render the key columns as (pk, ck...) or ((pk1, pk2), ck...)
+func primaryKeyClause(tableName string, columns []CqlColumn) (string, error) {
+	pkKeys := keyColumns(columns, "partition_key")
+	ckKeys := keyColumns(columns, "clustering")
+	pkSql := strings.Join(pkKeys, ", ")
+	if len(pkKeys) > 1 {
+		pkSql = "(" + pkSql + ")"
+	} else if len(pkKeys) == 0 {
+		return "", errors.New(tableName + " must have at least one Partition Key")
+	}
+	ckSql := strings.Join(ckKeys, ", ")
+	if len(ckKeys) > 0 {
+		ckSql = ", " + ckSql
+	}
+	return "(" + pkSql + ckSql + ")", nil
+}
+
+// Render the WITH clause, the clustering order is only rendered if any clustering key is descending
+func renderTableOptions(columns []CqlColumn, options TableOptions) string {
+	clauses := make([]string, 0)
+	orders := make([]string, 0)
+	hasDesc := false
+	for _, column := range columns {
+		if column.Name == "-" || !column.ClusteringKey {
+			continue
+		}
+		order := "ASC"
+		if column.Descending {
+			order = "DESC"
+			hasDesc = true
+		}
+		orders = append(orders, column.Name+" "+order)
+	}
+	if hasDesc {
+		clauses = append(clauses, fmt.Sprintf("CLUSTERING ORDER BY (%s)", strings.Join(orders, ", ")))
+	}
+	if len(options.Compaction) > 0 {
+		clauses = append(clauses, "compaction = "+renderCqlMap(options.Compaction))
+	}
+	if options.GcGraceSeconds != nil {
+		clauses = append(clauses, fmt.Sprintf("gc_grace_seconds = %d", *options.GcGraceSeconds))
+	}
+	if options.DefaultTTL > 0 {
+		clauses = append(clauses, fmt.Sprintf("default_time_to_live = %d", int(options.DefaultTTL/time.Second)))
+	}
+	if options.Comment != "" {
+		clauses = append(clauses, "comment = "+quoteCqlString(options.Comment))
+	}
+	if len(clauses) == 0 {
+		return ""
+	}
+	return " WITH " + strings.Join(clauses, " AND ")
+}
+
+// Render a map literal with sorted keys, class first
+func renderCqlMap(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i] == "class" || keys[j] == "class" {
+			return keys[i] == "class"
+		}
+		return keys[i] < keys[j]
+	})
+	entries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, fmt.Sprintf("%s: %s", quoteCqlString(key), quoteCqlString(values[key])))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+func quoteCqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,164 @@
+package nosqlorm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_CreateTableStatement(t *testing.T) {
+	columns := make([]CqlColumn, 0)
+	typ := reflect.TypeOf(testPerson{})
+	for i := 0; i < typ.NumField(); i++ {
+		column, err := ParseCqlColumn(typ.Field(i).Type.String(), typ.Field(i).Tag)
+		assert.NoError(t, err)
+		columns = append(columns, column)
+	}
+	assert.Equal(t, CqlColumn{Name: "name", Type: "text", PartitionKey: true}, columns[0])
+	assert.Equal(t, CqlColumn{Name: "-"}, columns[3])
+	sql, err := createTableStatement("testperson", columns, TableOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS testperson (name text, age tinyint, address text, PRIMARY KEY (name, age));", sql)
+
+	_, err = ParseCqlColumn("string", `json:"name" cql:"pk,foo"`)
+	assert.Error(t, err)
+	_, err = createTableStatement("nokey", []CqlColumn{{Name: "name", Type: "text"}}, TableOptions{})
+	assert.Error(t, err)
+}
+
+func Test_GenerateDDL(t *testing.T) {
+	ddl, err := GenerateDDL(testPerson{}, &testPageView{})
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS testperson (name text, age tinyint, address text, PRIMARY KEY (name, age));\n"+
+		"CREATE TABLE IF NOT EXISTS testpageview (page text, views counter, PRIMARY KEY (page));\n", ddl)
+
+	_, err = GenerateDDL("person")
+	assert.Error(t, err)
+	_, err = GenerateDDL(struct {
+		Name string `json:"name"`
+	}{})
+	assert.Error(t, err)
+}
+
+type testSensorReading struct {
+	Sensor string    `json:"sensor" cql:"pk"`
+	Day    time.Time `json:"day" cql:"pk,date"`
+	Ts     time.Time `json:"ts" cql:"ck"`
+	Value  float64   `json:"value"`
+}
+
+func (*testSensorReading) TableOptions() TableOptions {
+	return TableOptions{ClusteringOrder: []Ordering{Desc("ts")}}
+}
+
+func Test_TableOptionsDDL(t *testing.T) {
+	ddl, err := GenerateDDL(testTimeline{}, testSensorReading{})
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS testtimeline (user_id text, ts timestamp, seq bigint, body text, PRIMARY KEY (user_id, ts, seq))"+
+		" WITH CLUSTERING ORDER BY (ts DESC, seq ASC)"+
+		" AND compaction = {'class': 'TimeWindowCompactionStrategy', 'compaction_window_unit': 'DAYS'}"+
+		" AND gc_grace_seconds = 3600 AND default_time_to_live = 86400 AND comment = 'user''s timeline';\n"+
+		"CREATE TABLE IF NOT EXISTS testsensorreading (sensor text, day date, ts timestamp, value double, PRIMARY KEY ((sensor, day), ts))"+
+		" WITH CLUSTERING ORDER BY (ts DESC);\n", ddl)
+
+	// Clustering order must list the clustering keys in key order
+	columns := []CqlColumn{{Name: "id", Type: "text", PartitionKey: true}, {Name: "a", Type: "int", ClusteringKey: true}, {Name: "b", Type: "int", ClusteringKey: true}}
+	assert.Error(t, applyClusteringOrder("t", columns, []Ordering{Desc("b"), Asc("a")}))
+	assert.Error(t, applyClusteringOrder("t", columns, []Ordering{Desc("a")}))
+	assert.NoError(t, applyClusteringOrder("t", columns, []Ordering{Desc("a"), Asc("b")}))
+	assert.True(t, columns[1].Descending)
+
+	// Order tags are only allowed on clustering keys
+	_, err = ParseCqlColumn("string", `json:"name" cql:"pk,desc"`)
+	assert.Error(t, err)
+	_, err = ParseCqlColumn("string", `json:"name" cql:"ck,asc,desc"`)
+	assert.Error(t, err)
+	column, err := ParseCqlColumn("string", `json:"name" cql:"ck,desc"`)
+	assert.NoError(t, err)
+	assert.True(t, column.Descending)
+
+	// A fraction of a second would be truncated, a default TTL under a second would never expire
+	_, err = GenerateDDL(testShortTTL{})
+	assert.EqualError(t, err, "Invalid table options of testshortttl: Invalid TTL 500ms: must be a whole number of seconds")
+}
+
+type testShortTTL struct {
+	Id string `json:"id" cql:"pk"`
+}
+
+func (testShortTTL) TableOptions() TableOptions {
+	return TableOptions{DefaultTTL: 500 * time.Millisecond}
+}
+
+type testUserEvent struct {
+	UserId string    `json:"user_id" cql:"pk"`
+	Ts     time.Time `json:"ts" cql:"ck"`
+	Kind   string    `json:"kind"`
+}
+
+func (testUserEvent) TableName() string {
+	return "user_events"
+}
+
+func Test_TableName(t *testing.T) {
+	ddl, err := GenerateDDL(testUserEvent{})
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS user_events (user_id text, ts timestamp, kind text, PRIMARY KEY (user_id, ts));\n", ddl)
+
+	orm, err := NewCqlOrm[testUserEvent](nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "user_events", orm.tableName())
+	sql, _, err := buildInsertStatement(reflect.ValueOf(testUserEvent{UserId: "u1", Ts: time.Now()}), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO user_events (user_id,ts,kind) VALUES (?,?,?);", sql)
+
+	// Per instance table, such as a monthly table
+	monthly, err := NewCqlOrm[testUserEvent](nil, WithTableName("user_events_202401"))
+	assert.NoError(t, err)
+	ddl, err = monthly.GenerateDDL()
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS user_events_202401 (user_id text, ts timestamp, kind text, PRIMARY KEY (user_id, ts));\n", ddl)
+	sql, _, err = buildInsertStatement(reflect.ValueOf(testUserEvent{UserId: "u1", Ts: time.Now()}), statementOptions{table: monthly.opts.tableName})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO user_events_202401 (user_id,ts,kind) VALUES (?,?,?);", sql)
+	sql, _, _, err = buildSelectStatement(reflect.ValueOf(testUserEvent{UserId: "u1"}), monthly.opts.tableName, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT user_id, ts, kind FROM user_events_202401 WHERE user_id=? AND ts=?;", sql)
+	sql, _, _, err = monthly.Query().Where(Eq("user_id", "u1")).Limit(10).build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT user_id, ts, kind FROM user_events_202401 WHERE user_id=? LIMIT ?;", sql)
+	sql, _, err = buildDeleteStatement(reflect.ValueOf(testUserEvent{UserId: "u1"}), statementOptions{table: monthly.opts.tableName})
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM user_events_202401 WHERE user_id=? AND ts=?;", sql)
+
+	// Names are lower-cased like Cassandra does, so schema lookups find the table
+	mixed, err := NewCqlOrm[testUserEvent](nil, WithTableName("User_Events_202402"))
+	assert.NoError(t, err)
+	assert.Equal(t, "user_events_202402", mixed.tableName())
+	assert.Equal(t, "user_events", modelTableName(reflect.TypeOf(testMixedCaseEvent{})))
+
+	// Table names are identifiers, they are never rendered into DDL unchecked
+	_, err = NewCqlOrm[testUserEvent](nil, WithTableName("ev (id text PRIMARY KEY); DROP TABLE users; --"))
+	assert.EqualError(t, err, "Invalid table name ev (id text primary key); drop table users; --")
+	_, err = NewCqlOrm[testUserEvent](nil, WithTableName("shop.user_events"))
+	assert.Error(t, err)
+	_, err = GenerateDDL(testBadTableName{})
+	assert.EqualError(t, err, "Invalid table name bad-name")
+}
+
+type testBadTableName struct {
+	Id string `json:"id" cql:"pk"`
+}
+
+func (testBadTableName) TableName() string {
+	return "bad-name"
+}
+
+type testMixedCaseEvent struct {
+	UserId string `json:"user_id" cql:"pk"`
+}
+
+func (testMixedCaseEvent) TableName() string {
+	return "User_Events"
+}
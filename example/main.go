@@ -11,6 +11,8 @@ import (
 	"time"
 )
 
+//go:generate go run github.com/Tonyzhuwei/nosqlorm/cmd/nosqlorm-gen -type Person
+
 type Person struct {
 	Name        string     `json:"name" cql:"pk"`
 	Age         int8       `json:"age" cql:"ck"`
@@ -29,6 +31,14 @@ func (app *App) QueryPerson(person Person) ([]Person, error) {
 	return result, err
 }
 
+func (app *App) QueryOlderPersons(name string, age int8, limit int) ([]Person, error) {
+	return app.personTable.Find(nosqlorm.QuerySpec{
+		Filters: []nosqlorm.Condition{nosqlorm.Eq("name", name), nosqlorm.Gt("age", age)},
+		OrderBy: []nosqlorm.Ordering{nosqlorm.Desc("age")},
+		Limit:   limit,
+	})
+}
+
 func (app *App) DeletePerson(person Person) error {
 	err := app.personTable.Delete(person)
 	if err != nil {
@@ -67,12 +77,23 @@ var cpuprofile = flag.String("cpuprofile", "pprof.profile", "write cpu profile t
 func main() {
 	// Create Cassandra connect session.
 	clustser := gocql.NewCluster("localhost:9042")
-	clustser.Keyspace = "cqlorm"
 	sess, err := clustser.CreateSession()
 	if err != nil {
 		panic(err)
 	}
 
+	// Create keyspace if not existing, then bind the session to it
+	err = nosqlorm.CreateKeyspace(sess, "cqlorm", nosqlorm.SimpleStrategy(1))
+	if err != nil {
+		panic(err)
+	}
+	sess.Close()
+	clustser.Keyspace = "cqlorm"
+	sess, err = clustser.CreateSession()
+	if err != nil {
+		panic(err)
+	}
+
 	// Create tables if not existing
 	err = nosqlorm.CreateCassandraTables(sess, Person{})
 	if err != nil {
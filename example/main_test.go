@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/Tonyzhuwei/nosqlorm"
+	"github.com/gocql/gocql"
+	"strings"
 	"testing"
 )
 
@@ -45,3 +49,145 @@ func Test_Update(t *testing.T) {
 	mockPersonTable.AddOtherExpectation(testPerson, nil)
 	mockApp.UpdatePerson(testPerson)
 }
+
+func Test_QueryCtx(t *testing.T) {
+	sess := nosqlorm.NewMockSession(t)
+	mockPersonTable := nosqlorm.NewMockTable[Person](sess)
+	var personTable nosqlorm.NoSqlOrm[Person] = mockPersonTable
+	mockPersonTable.AddSelectExpectation(testPerson, []Person{testPerson})
+
+	result, err := personTable.SelectCtx(context.Background(), testPerson)
+	if err != nil || len(result) != 1 {
+		t.Errorf("unexpected select result: %v, %v", result, err)
+	}
+}
+
+func Test_Batch(t *testing.T) {
+	sess := nosqlorm.NewMockSession(t)
+	mockPersonTable := nosqlorm.NewMockTable[Person](sess)
+	mockPersonTable.AddBatchExpectation(gocql.LoggedBatch, []nosqlorm.BatchEntry[Person]{
+		{Op: nosqlorm.InsertOp, Obj: testPerson},
+		{Op: nosqlorm.DeleteOp, Obj: Person{Name: "Tony", Age: 29}},
+	}, nil)
+
+	batch := mockPersonTable.NewBatch(gocql.LoggedBatch)
+	batch.Insert(testPerson)
+	batch.Delete(Person{Name: "Tony", Age: 29})
+	if err := batch.Exec(); err != nil {
+		t.Error(err)
+	}
+}
+
+type PersonByCity struct {
+	City string `json:"city" cql:"pk"`
+	Name string `json:"name" cql:"ck"`
+}
+
+func Test_UnitOfWork(t *testing.T) {
+	sess := nosqlorm.NewMockSession(t)
+	uow := nosqlorm.NewMockUnitOfWork(sess)
+	sess.AddUnitOfWorkExpectation([]nosqlorm.UnitOfWorkEntry{
+		{Op: nosqlorm.InsertOp, Obj: testPerson},
+		{Op: nosqlorm.InsertOp, Obj: PersonByCity{City: "Sydney", Name: "Tony"}},
+	}, nil)
+
+	var work nosqlorm.UnitOfWork = uow
+	work.Insert(testPerson)
+	work.Insert(PersonByCity{City: "Sydney", Name: "Tony"})
+	if err := work.Commit(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_InsertIfNotExists(t *testing.T) {
+	sess := nosqlorm.NewMockSession(t)
+	mockPersonTable := nosqlorm.NewMockTable[Person](sess)
+	existing := Person{Name: "Tony", Age: 30, Address: "existing address"}
+	mockPersonTable.AddLWTExpectation(nosqlorm.InsertOp, testPerson, nil, false, existing, nil)
+	mockPersonTable.AddLWTExpectation(nosqlorm.UpdateOp, testPerson, []nosqlorm.Condition{nosqlorm.Eq("address", "existing address")}, true, Person{}, nil)
+
+	applied, current, err := mockPersonTable.InsertIfNotExists(testPerson)
+	if err != nil || applied || current.Address != existing.Address {
+		t.Errorf("unexpected LWT result: %v, %v, %v", applied, current, err)
+	}
+	applied, _, err = mockPersonTable.UpdateIf(testPerson, nosqlorm.Eq("address", "existing address"))
+	if err != nil || !applied {
+		t.Errorf("unexpected LWT result: %v, %v", applied, err)
+	}
+}
+
+func Test_SelectPages(t *testing.T) {
+	sess := nosqlorm.NewMockSession(t)
+	mockPersonTable := nosqlorm.NewMockTable[Person](sess)
+	query := Person{Name: "Tony"}
+	mockPersonTable.AddSelectPagesExpectation(query, 1, []Person{testPerson}, []Person{testPerson})
+
+	var personTable nosqlorm.NoSqlOrm[Person] = mockPersonTable
+	count := 0
+	var cursor []byte
+	for {
+		rows, next, err := personTable.SelectPage(query, 1, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		count += len(rows)
+		if next == nil {
+			break
+		}
+		cursor = next
+	}
+	if count != 2 {
+		t.Errorf("expected 2 rows, got %d", count)
+	}
+}
+
+func Test_SelectEach(t *testing.T) {
+	sess := nosqlorm.NewMockSession(t)
+	mockPersonTable := nosqlorm.NewMockTable[Person](sess)
+	query := Person{Name: "Tony"}
+	streamErr := errors.New("connection lost")
+	mockPersonTable.AddSelectStreamExpectation(query, []Person{testPerson, testPerson, testPerson}, nil)
+	mockPersonTable.AddSelectStreamExpectation(query, []Person{testPerson}, streamErr)
+
+	count := 0
+	err := mockPersonTable.SelectEach(query, func(person Person) error {
+		count++
+		if count == 2 {
+			return nosqlorm.ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil || count != 2 {
+		t.Errorf("unexpected stream result: %d, %v", count, err)
+	}
+
+	err = mockPersonTable.SelectEach(query, func(person Person) error { return nil })
+	if !errors.Is(err, streamErr) {
+		t.Errorf("expected stream error, got %v", err)
+	}
+}
+
+func Test_Find(t *testing.T) {
+	sess := nosqlorm.NewMockSession(t)
+	mockPersonTable := nosqlorm.NewMockTable[Person](sess)
+	mockApp := App{
+		personTable: mockPersonTable,
+	}
+	mockPersonTable.AddFindExpectation(nosqlorm.QuerySpec{
+		Filters: []nosqlorm.Condition{nosqlorm.Eq("name", "Tony"), nosqlorm.Gt("age", int8(18))},
+		OrderBy: []nosqlorm.Ordering{nosqlorm.Desc("age")},
+		Limit:   10,
+	}, []Person{testPerson}, nil)
+
+	result, err := mockApp.QueryOlderPersons("Tony", 18, 10)
+	if err != nil || len(result) != 1 {
+		t.Errorf("unexpected find result: %v, %v", result, err)
+	}
+}
+
+func Test_GeneratedDDL(t *testing.T) {
+	ddl, err := PersonDDL()
+	if err != nil || !strings.HasPrefix(ddl, "CREATE TABLE IF NOT EXISTS person (") {
+		t.Errorf("unexpected generated DDL: %s, %v", ddl, err)
+	}
+}
@@ -0,0 +1,45 @@
+// Code generated by nosqlorm-gen. DO NOT EDIT.
+
+package main
+
+import "github.com/Tonyzhuwei/nosqlorm"
+
+func init() {
+	nosqlorm.RegisterMapper(nosqlorm.ModelMapper[Person]{
+		Columns: []string{"name", "age", "address", "-", "lucky_number", "created_time"},
+		Values: func(obj Person) []interface{} {
+			values := make([]interface{}, 6)
+			values[0] = obj.Name
+			values[1] = obj.Age
+			values[2] = obj.Address
+			values[4] = obj.LuckyNumber
+			if obj.CreatedTime != nil {
+				values[5] = *obj.CreatedTime
+			}
+			return values
+		},
+		Pointers: func(obj *Person, columns []string) []interface{} {
+			pointers := make([]interface{}, 0, len(columns))
+			for _, column := range columns {
+				switch column {
+				case "name":
+					pointers = append(pointers, &obj.Name)
+				case "age":
+					pointers = append(pointers, &obj.Age)
+				case "address":
+					pointers = append(pointers, &obj.Address)
+				case "lucky_number":
+					pointers = append(pointers, &obj.LuckyNumber)
+				case "created_time":
+					pointers = append(pointers, &obj.CreatedTime)
+				}
+			}
+			return pointers
+		},
+	})
+}
+
+// PersonDDL Render the CREATE statements of Person, the same DDL CreateCassandraTables runs.
+func PersonDDL() (string, error) {
+	return nosqlorm.GenerateDDL(Person{})
+}
@@ -0,0 +1,122 @@
+package nosqlorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// FieldMask names the columns a Select uses, so that zero values could be told apart from "not specified".
+type FieldMask struct {
+	// Key columns used as filter, their values are taken from the model object even if they are zero
+	Filter []string
+	// Columns to fetch, empty means all columns
+	Columns []string
+}
+
+// UpdateFields Only set the named columns, zero values and nil pointers of them are written as is.
+// Primary key columns are always taken from the model object.
+func (ctx *cqlOrm[T]) UpdateFields(obj T, columns ...string) error {
+	return ctx.UpdateFieldsCtx(context.Background(), obj, columns...)
+}
+
+// UpdateFieldsCtx is the same as UpdateFields, the query is bound to the given context.
+func (ctx *cqlOrm[T]) UpdateFieldsCtx(c context.Context, obj T, columns ...string) error {
+	schema, _, err := loadTableSchema(reflect.TypeOf(obj), "")
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return errors.New("UpdateFields requires at least one column")
+	}
+	if err := checkMaskColumns(schema, columns, false); err != nil {
+		return err
+	}
+	sql, sqlParams, err := buildUpdateStatement(reflect.ValueOf(obj), statementOptions{writeOptions: ctx.opts.writeOptions, mask: toMask(columns), table: ctx.table()})
+	if err != nil {
+		return err
+	}
+	return ctx.sess.Query(sql, sqlParams...).WithContext(c).Exec()
+}
+
+// SelectFields Select with an explicit filter and projection instead of the non-nil key fields of the model object.
+func (ctx *cqlOrm[T]) SelectFields(obj T, mask FieldMask) ([]T, error) {
+	return ctx.SelectFieldsCtx(context.Background(), obj, mask)
+}
+
+// SelectFieldsCtx is the same as SelectFields, the query is bound to the given context.
+func (ctx *cqlOrm[T]) SelectFieldsCtx(c context.Context, obj T, mask FieldMask) ([]T, error) {
+	sql, selectFields, whereValues, err := buildSelectStatement(reflect.ValueOf(obj), ctx.table(), &mask)
+	if err != nil {
+		return []T{}, err
+	}
+	rows := make([]T, 0)
+	err = ctx.selectEach(c, sql, selectFields, whereValues, func(row T) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return []T{}, err
+	}
+	return rows, nil
+}
+
+// DeleteFields Delete with only the named key columns as filter, zero values of them are used as is.
+func (ctx *cqlOrm[T]) DeleteFields(obj T, keys ...string) error {
+	return ctx.DeleteFieldsCtx(context.Background(), obj, keys...)
+}
+
+// DeleteFieldsCtx is the same as DeleteFields, the query is bound to the given context.
+func (ctx *cqlOrm[T]) DeleteFieldsCtx(c context.Context, obj T, keys ...string) error {
+	schema, _, err := loadTableSchema(reflect.TypeOf(obj), "")
+	if err != nil {
+		return err
+	}
+	if err := checkMaskColumns(schema, keys, true); err != nil {
+		return err
+	}
+	sql, whereValues, err := buildDeleteStatement(reflect.ValueOf(obj), statementOptions{mask: toMask(keys), table: ctx.table()})
+	if err != nil {
+		return err
+	}
+	return ctx.sess.Query(sql, whereValues...).WithContext(c).Exec()
+}
+
+func toMask(columns []string) map[string]bool {
+	mask := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		mask[column] = true
+	}
+	return mask
+}
+
+// Validate masked columns against the table schema, they must all be key columns or all be non-key columns.
+func checkMaskColumns(schema tableSchema, columns []string, keys bool) error {
+	for _, column := range columns {
+		field, ok := schema.fieldMap[column]
+		if !ok || column == "-" {
+			return errors.New(fmt.Sprintf("Invalid field mask: column %s not found", column))
+		}
+		isKey := field.isPartitionKey || field.isClusteringKey
+		if keys && !isKey {
+			return errors.New(fmt.Sprintf("Invalid field mask: %s is not a key column", column))
+		}
+		if !keys && isKey {
+			return errors.New(fmt.Sprintf("Invalid field mask: key column %s could not be updated", column))
+		}
+	}
+	return nil
+}
+
+// Value of a field and whether it takes part in the statement. Without a mask nil pointers are skipped,
+// with a mask only the named columns take part, and nil pointers of them are written as NULL.
+func maskedFieldValue(fieldVal interface{}, column string, mask map[string]bool) (interface{}, bool) {
+	if mask == nil {
+		return fieldVal, fieldVal != nil
+	}
+	if !mask[column] {
+		return nil, false
+	}
+	return fieldVal, true
+}
@@ -0,0 +1,60 @@
+package nosqlorm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type testProfile struct {
+	Name    string  `json:"name" cql:"pk"`
+	Age     int8    `json:"age" cql:"ck"`
+	Address string  `json:"address"`
+	Score   int     `json:"score"`
+	Note    *string `json:"note"`
+}
+
+func Test_BuildStatementsWithFieldMask(t *testing.T) {
+	_, err := NewCqlOrm[testProfile](nil)
+	assert.NoError(t, err)
+	profile := testProfile{Name: "tony", Age: 0, Address: "street", Score: 0}
+
+	// Zero values and nil pointers of named columns are written
+	sql, values, err := buildUpdateStatement(reflect.ValueOf(profile), statementOptions{mask: toMask([]string{"score", "note"})})
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE testprofile SET score=?,note=? WHERE name=? AND age=?;", sql)
+	assert.Equal(t, []interface{}{0, nil, "tony", int8(0)}, values)
+
+	sql, values, err = buildDeleteStatement(reflect.ValueOf(profile), statementOptions{mask: toMask([]string{"name"})})
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM testprofile WHERE name=?;", sql)
+	assert.Equal(t, []interface{}{"tony"}, values)
+
+	columns := []string{"age", "address"}
+	sql, fields, values, err := buildSelectStatement(reflect.ValueOf(profile), "", &FieldMask{Filter: []string{"name"}, Columns: columns})
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT age, address FROM testprofile WHERE name=?;", sql)
+	assert.Equal(t, []string{"age", "address"}, fields)
+	assert.Equal(t, []interface{}{"tony"}, values)
+
+	// Reusing the columns slice of the caller does not change the cached statement
+	columns[0] = "note"
+	_, fields, _, err = buildSelectStatement(reflect.ValueOf(profile), "", &FieldMask{Filter: []string{"name"}, Columns: []string{"age", "address"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"age", "address"}, fields)
+
+	_, _, _, err = buildSelectStatement(reflect.ValueOf(profile), "", &FieldMask{Filter: []string{"address"}})
+	assert.Error(t, err)
+	_, _, _, err = buildSelectStatement(reflect.ValueOf(profile), "", &FieldMask{Filter: []string{"name"}, Columns: []string{"unknown"}})
+	assert.Error(t, err)
+}
+
+func Test_CheckMaskColumns(t *testing.T) {
+	schema, err := registerModel(reflect.TypeOf(testProfile{}))
+	assert.NoError(t, err)
+	assert.NoError(t, checkMaskColumns(schema, []string{"address", "note"}, false))
+	assert.Error(t, checkMaskColumns(schema, []string{"name"}, false))
+	assert.Error(t, checkMaskColumns(schema, []string{"unknown"}, false))
+	assert.NoError(t, checkMaskColumns(schema, []string{"name", "age"}, true))
+	assert.Error(t, checkMaskColumns(schema, []string{"score"}, true))
+}
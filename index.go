@@ -0,0 +1,122 @@
+package nosqlorm
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gocql/gocql"
+	"strings"
+)
+
+// Index classes of custom indexes by index kind
+var indexClasses = map[string]string{
+	"sai":  "StorageAttachedIndex",
+	"sasi": "org.apache.cassandra.index.sasi.SASIIndex",
+}
+
+// Name of the index on a column, the same name Cassandra gives to unnamed indexes by default
+func (column CqlColumn) indexName(tableName string) string {
+	if column.IndexName != "" {
+		return column.IndexName
+	}
+	return fmt.Sprintf("%s_%s_idx", tableName, column.Name)
+}
+
+func (def tableDefinition) createIndexStatement(column CqlColumn) string {
+	// Index names are not qualified, indexes are created in the keyspace of their table
+	indexName := column.indexName(def.name)
+	if class, ok := indexClasses[column.Index]; ok {
+		return fmt.Sprintf("CREATE CUSTOM INDEX IF NOT EXISTS %s ON %s (%s) USING %s;", indexName, def.qualifiedName(), column.Name, quoteCqlString(class))
+	}
+	return fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s);", indexName, def.qualifiedName(), column.Name)
+}
+
+// Index read from system_schema.indexes
+type liveIndex struct {
+	name string
+	// sai, sasi or legacy, the class name for other custom indexes
+	kind   string
+	column string
+}
+
+func (index liveIndex) definition() string {
+	return fmt.Sprintf("%s index on %s", index.kind, index.column)
+}
+
+func readTableIndexes(sess *gocql.Session, keyspace string, tableName string) ([]liveIndex, error) {
+	iter := sess.Query("SELECT index_name, kind, options FROM system_schema.indexes WHERE keyspace_name = ? AND table_name = ?;",
+		keyspace, tableName).Iter()
+	indexes := make([]liveIndex, 0)
+	var name, kind string
+	var options map[string]string
+	for iter.Scan(&name, &kind, &options) {
+		indexes = append(indexes, parseLiveIndex(name, kind, options))
+	}
+	if err := iter.Close(); err != nil {
+		return nil, errors.New(fmt.Sprintf("Read indexes of table %s failed: %s", tableName, err.Error()))
+	}
+	return indexes, nil
+}
+
+func parseLiveIndex(name string, kind string, options map[string]string) liveIndex {
+	index := liveIndex{name: name, kind: "legacy", column: options["target"]}
+	// Collection targets are wrapped such as values(tags)
+	if open := strings.IndexByte(index.column, '('); open >= 0 && strings.HasSuffix(index.column, ")") {
+		index.column = index.column[open+1 : len(index.column)-1]
+	}
+	if kind == "CUSTOM" {
+		index.kind = options["class_name"]
+		for indexKind, class := range indexClasses {
+			// Classes are stored with their package name
+			if class == index.kind || strings.HasSuffix(index.kind, "."+class) {
+				index.kind = indexKind
+			}
+		}
+	}
+	return index
+}
+
+// Compare indexes declared by the model with the live table. Missing indexes are returned as mismatches
+// with ErrMissingIndex, they could be created. Indexes only existing in the table are left alone.
+func diffTableIndexes(def tableDefinition, live []liveIndex) []ColumnMismatch {
+	liveMap := make(map[string]liveIndex, len(live))
+	for _, index := range live {
+		liveMap[index.name] = index
+	}
+	mismatches := make([]ColumnMismatch, 0)
+	for _, column := range def.columns {
+		if column.Name == "-" || column.Index == "" {
+			continue
+		}
+		indexName := column.indexName(def.name)
+		model := liveIndex{name: indexName, kind: column.Index, column: column.Name}
+		liveIdx, ok := liveMap[indexName]
+		switch {
+		case !ok:
+			mismatches = append(mismatches, ColumnMismatch{Column: indexName, Model: model.definition(), Err: ErrMissingIndex})
+		case liveIdx.kind != model.kind || liveIdx.column != model.column:
+			mismatches = append(mismatches, ColumnMismatch{Column: indexName, Model: model.definition(), Table: liveIdx.definition(), Err: ErrIndexChanged})
+		}
+	}
+	return mismatches
+}
+
+// CREATE INDEX statements of the missing indexes, changed indexes could not be applied and are returned as an error
+func createIndexStatements(def tableDefinition, live []liveIndex) ([]string, error) {
+	statements := make([]string, 0)
+	incompatible := make([]ColumnMismatch, 0)
+	for _, mismatch := range diffTableIndexes(def, live) {
+		if mismatch.Err != ErrMissingIndex {
+			incompatible = append(incompatible, mismatch)
+			continue
+		}
+		for _, column := range def.columns {
+			if column.Name != "-" && column.Index != "" && column.indexName(def.name) == mismatch.Column {
+				statements = append(statements, def.createIndexStatement(column))
+			}
+		}
+	}
+	if len(incompatible) > 0 {
+		return nil, &SchemaError{Table: def.qualifiedName(), Mismatches: incompatible}
+	}
+	return statements, nil
+}
@@ -0,0 +1,104 @@
+package nosqlorm
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type testCustomer struct {
+	Tenant  string   `json:"tenant" cql:"pk"`
+	Id      string   `json:"id" cql:"ck"`
+	Email   string   `json:"email" cql:"index=sai"`
+	Country string   `json:"country" cql:"index,index_name=customer_country"`
+	Score   int      `json:"score" cql:"index=sasi"`
+	Tags    []string `json:"tags" cql:"index=legacy"`
+	Note    string   `json:"note"`
+}
+
+func Test_IndexDDL(t *testing.T) {
+	ddl, err := GenerateDDL(testCustomer{})
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS testcustomer (tenant text, id text, email text, country text, score bigint, tags list<text>, note text, PRIMARY KEY (tenant, id));\n"+
+		"CREATE CUSTOM INDEX IF NOT EXISTS testcustomer_email_idx ON testcustomer (email) USING 'StorageAttachedIndex';\n"+
+		"CREATE INDEX IF NOT EXISTS customer_country ON testcustomer (country);\n"+
+		"CREATE CUSTOM INDEX IF NOT EXISTS testcustomer_score_idx ON testcustomer (score) USING 'org.apache.cassandra.index.sasi.SASIIndex';\n"+
+		"CREATE INDEX IF NOT EXISTS testcustomer_tags_idx ON testcustomer (tags);\n", ddl)
+
+	// Index names are not qualified by the keyspace
+	ddl, err = GenerateDDLInKeyspace("tenant_a", testCustomer{})
+	assert.NoError(t, err)
+	assert.Contains(t, ddl, "CREATE INDEX IF NOT EXISTS customer_country ON tenant_a.testcustomer (country);\n")
+}
+
+func Test_InvalidIndexTag(t *testing.T) {
+	for _, cql := range []string{"index=btree", "index_name=email_idx", "pk,index", "index,index_name=bad-name", "counter,index"} {
+		_, err := ParseCqlColumn("int64", reflect.StructTag(`json:"email" cql:"`+cql+`"`))
+		assert.Error(t, err, cql)
+	}
+	column, err := ParseCqlColumn("string", `json:"id" cql:"ck,index=sai,index_name=By_Id"`)
+	assert.NoError(t, err)
+	assert.Equal(t, CqlColumn{Name: "id", Type: "text", ClusteringKey: true, Index: "sai", IndexName: "by_id"}, column)
+}
+
+func Test_DiffTableIndexes(t *testing.T) {
+	def, err := typeTableDefinition(reflect.TypeOf(testCustomer{}))
+	assert.NoError(t, err)
+	live := []liveIndex{
+		parseLiveIndex("testcustomer_email_idx", "CUSTOM", map[string]string{"target": "email", "class_name": "org.apache.cassandra.index.sai.StorageAttachedIndex"}),
+		parseLiveIndex("customer_country", "COMPOSITES", map[string]string{"target": "country"}),
+		parseLiveIndex("testcustomer_tags_idx", "COMPOSITES", map[string]string{"target": "values(tags)"}),
+		parseLiveIndex("testcustomer_note_idx", "COMPOSITES", map[string]string{"target": "note"}),
+	}
+	assert.Equal(t, liveIndex{name: "testcustomer_email_idx", kind: "sai", column: "email"}, live[0])
+	assert.Equal(t, liveIndex{name: "testcustomer_tags_idx", kind: "legacy", column: "tags"}, live[2])
+
+	// Missing indexes are created, indexes only existing in the table are left alone
+	statements, err := createIndexStatements(def, live)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CREATE CUSTOM INDEX IF NOT EXISTS testcustomer_score_idx ON testcustomer (score) USING 'org.apache.cassandra.index.sasi.SASIIndex';"}, statements)
+
+	live[1].kind = "sai"
+	_, err = createIndexStatements(def, live)
+	assert.True(t, errors.Is(err, ErrIndexChanged))
+	assert.Equal(t, "table testcustomer does not match the model: customer_country: index changed (model: legacy index on country, table: sai index on country)", err.Error())
+
+	mismatches := diffTableIndexes(def, live[:1])
+	assert.Len(t, mismatches, 3)
+	assert.Equal(t, ErrMissingIndex, mismatches[0].Err)
+}
+
+func Test_IndexedQuery(t *testing.T) {
+	orm, err := NewCqlOrm[testCustomer](nil)
+	assert.NoError(t, err)
+
+	sql, _, values, err := orm.Query().Where(Eq("email", "a@b.c")).build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT tenant, id, email, country, score, tags, note FROM testcustomer WHERE email=?;", sql)
+	assert.Equal(t, []interface{}{"a@b.c"}, values)
+
+	sql, _, _, err = orm.Query().Where(Gt("score", 10), Eq("country", "AU")).Limit(10).build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT tenant, id, email, country, score, tags, note FROM testcustomer WHERE score>? AND country=? LIMIT ?;", sql)
+
+	// Indexed columns within a partition
+	sql, _, _, err = orm.Query().Where(Eq("tenant", "t1"), Eq("email", "a@b.c")).build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT tenant, id, email, country, score, tags, note FROM testcustomer WHERE tenant=? AND email=?;", sql)
+
+	_, _, _, err = orm.Query().Where(Eq("note", "x")).build()
+	assert.EqualError(t, err, "Invalid filter: note is not a key or indexed column")
+	_, _, _, err = orm.Query().Where(Gt("country", "AU")).build()
+	assert.Error(t, err)
+	_, _, _, err = orm.Query().Where(In("email", "a@b.c", "d@e.f")).build()
+	assert.Error(t, err)
+	_, _, _, err = orm.Query().Where(Eq("country", "AU"), Eq("tags", "vip")).build()
+	assert.Error(t, err)
+	_, _, _, err = orm.Query().Where(Eq("tenant", "t1"), Eq("email", "a@b.c")).OrderBy(Desc("id")).build()
+	assert.Error(t, err)
+
+	// Non-indexed key columns still need the partition key
+	_, _, _, err = orm.Query().Where(Eq("email", "a@b.c"), Eq("id", "c1")).build()
+	assert.True(t, errors.Is(err, ErrMissingPartitionKey))
+}
@@ -0,0 +1,178 @@
+package nosqlorm
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gocql/gocql"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Replication strategy of a keyspace, created by SimpleStrategy or NetworkTopologyStrategy
+type Replication struct {
+	Class string
+	// Replication factor of SimpleStrategy
+	ReplicationFactor int
+	// Replication factors per data center of NetworkTopologyStrategy
+	DataCenters map[string]int
+}
+
+// SimpleStrategy Replicate to the next nodes of the ring, for single data center and test clusters
+func SimpleStrategy(replicationFactor int) Replication {
+	return Replication{Class: "SimpleStrategy", ReplicationFactor: replicationFactor}
+}
+
+// NetworkTopologyStrategy Replicate with a replication factor per data center, such as {"dc1": 3, "dc2": 2}
+func NetworkTopologyStrategy(dataCenters map[string]int) Replication {
+	return Replication{Class: "NetworkTopologyStrategy", DataCenters: dataCenters}
+}
+
+// Render the replication map of the strategy
+func (r Replication) render() (string, error) {
+	values := map[string]string{"class": r.Class}
+	switch r.Class {
+	case "SimpleStrategy":
+		if r.ReplicationFactor <= 0 {
+			return "", errors.New(fmt.Sprintf("Invalid replication factor %d: must be positive", r.ReplicationFactor))
+		}
+		values["replication_factor"] = strconv.Itoa(r.ReplicationFactor)
+	case "NetworkTopologyStrategy":
+		if len(r.DataCenters) == 0 {
+			return "", errors.New("Invalid replication: NetworkTopologyStrategy needs at least one data center")
+		}
+		dataCenters := make([]string, 0, len(r.DataCenters))
+		for dc := range r.DataCenters {
+			dataCenters = append(dataCenters, dc)
+		}
+		sort.Strings(dataCenters)
+		for _, dc := range dataCenters {
+			if dc == "" || dc == "class" || dc == "replication_factor" {
+				return "", errors.New(fmt.Sprintf("Invalid data center name '%s'", dc))
+			}
+			if r.DataCenters[dc] < 0 {
+				return "", errors.New(fmt.Sprintf("Invalid replication factor %d of data center %s: must not be negative", r.DataCenters[dc], dc))
+			}
+			values[dc] = strconv.Itoa(r.DataCenters[dc])
+		}
+	default:
+		return "", errors.New(fmt.Sprintf("Unsupported replication strategy '%s'", r.Class))
+	}
+	return renderCqlMap(values), nil
+}
+
+// KeyspaceOption Customize keyspace DDL
+type KeyspaceOption func(*keyspaceOptions)
+
+type keyspaceOptions struct {
+	durableWrites *bool
+}
+
+// WithDurableWrites Set durable_writes of the keyspace, Cassandra enables it by default.
+// Disabling it skips the commit log and risks data loss.
+func WithDurableWrites(enabled bool) KeyspaceOption {
+	return func(opts *keyspaceOptions) {
+		opts.durableWrites = &enabled
+	}
+}
+
+func renderKeyspaceOptions(replication Replication, options []KeyspaceOption) (string, error) {
+	opts := keyspaceOptions{}
+	for _, option := range options {
+		option(&opts)
+	}
+	clauses := make([]string, 0, 2)
+	if replication.Class != "" {
+		rendered, err := replication.render()
+		if err != nil {
+			return "", err
+		}
+		clauses = append(clauses, "replication = "+rendered)
+	}
+	if opts.durableWrites != nil {
+		clauses = append(clauses, fmt.Sprintf("durable_writes = %t", *opts.durableWrites))
+	}
+	return strings.Join(clauses, " AND "), nil
+}
+
+func validateKeyspaceName(keyspace string) error {
+	if !identifierPattern.MatchString(keyspace) {
+		return errors.New(fmt.Sprintf("Invalid keyspace %s", keyspace))
+	}
+	return nil
+}
+
+// CreateKeyspaceStatement Render the CREATE KEYSPACE statement, it could be put in front of the table DDL of GenerateDDL
+func CreateKeyspaceStatement(keyspace string, replication Replication, options ...KeyspaceOption) (string, error) {
+	if err := validateKeyspaceName(keyspace); err != nil {
+		return "", err
+	}
+	if replication.Class == "" {
+		return "", errors.New(fmt.Sprintf("Create keyspace %s: replication is required", keyspace))
+	}
+	clauses, err := renderKeyspaceOptions(replication, options)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("CREATE KEYSPACE IF NOT EXISTS %s WITH %s;", keyspace, clauses), nil
+}
+
+// AlterKeyspaceStatement Render the ALTER KEYSPACE statement, a zero Replication keeps the replication of the keyspace
+func AlterKeyspaceStatement(keyspace string, replication Replication, options ...KeyspaceOption) (string, error) {
+	if err := validateKeyspaceName(keyspace); err != nil {
+		return "", err
+	}
+	clauses, err := renderKeyspaceOptions(replication, options)
+	if err != nil {
+		return "", err
+	}
+	if clauses == "" {
+		return "", errors.New(fmt.Sprintf("Alter keyspace %s: nothing to alter", keyspace))
+	}
+	return fmt.Sprintf("ALTER KEYSPACE %s WITH %s;", keyspace, clauses), nil
+}
+
+// DropKeyspaceStatement Render the DROP KEYSPACE statement
+func DropKeyspaceStatement(keyspace string) (string, error) {
+	if err := validateKeyspaceName(keyspace); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("DROP KEYSPACE IF EXISTS %s;", keyspace), nil
+}
+
+// CreateKeyspace Create the keyspace if it does not exist, an existing keyspace is left unchanged
+func CreateKeyspace(sess *gocql.Session, keyspace string, replication Replication, options ...KeyspaceOption) error {
+	sql, err := CreateKeyspaceStatement(keyspace, replication, options...)
+	if err != nil {
+		return err
+	}
+	if err := sess.Query(sql).Exec(); err != nil {
+		return errors.New(fmt.Sprintf("Create keyspace %s failed: %s", keyspace, err.Error()))
+	}
+	return nil
+}
+
+// AlterKeyspace Change the replication or durable_writes of a keyspace.
+// Run a repair after raising replication factors, so that new replicas receive the existing data.
+func AlterKeyspace(sess *gocql.Session, keyspace string, replication Replication, options ...KeyspaceOption) error {
+	sql, err := AlterKeyspaceStatement(keyspace, replication, options...)
+	if err != nil {
+		return err
+	}
+	if err := sess.Query(sql).Exec(); err != nil {
+		return errors.New(fmt.Sprintf("Alter keyspace %s failed: %s", keyspace, err.Error()))
+	}
+	return nil
+}
+
+// DropKeyspace Drop the keyspace with all of its tables and data if it exists
+func DropKeyspace(sess *gocql.Session, keyspace string) error {
+	sql, err := DropKeyspaceStatement(keyspace)
+	if err != nil {
+		return err
+	}
+	if err := sess.Query(sql).Exec(); err != nil {
+		return errors.New(fmt.Sprintf("Drop keyspace %s failed: %s", keyspace, err.Error()))
+	}
+	return nil
+}
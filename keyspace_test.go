@@ -0,0 +1,44 @@
+package nosqlorm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_KeyspaceStatements(t *testing.T) {
+	sql, err := CreateKeyspaceStatement("cqlorm", SimpleStrategy(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE KEYSPACE IF NOT EXISTS cqlorm WITH replication = {'class': 'SimpleStrategy', 'replication_factor': '1'};", sql)
+
+	sql, err = CreateKeyspaceStatement("tenant_42", NetworkTopologyStrategy(map[string]int{"dc2": 2, "dc1": 3}), WithDurableWrites(false))
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE KEYSPACE IF NOT EXISTS tenant_42 WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': '3', 'dc2': '2'} AND durable_writes = false;", sql)
+
+	sql, err = AlterKeyspaceStatement("tenant_42", NetworkTopologyStrategy(map[string]int{"dc1": 5}))
+	assert.NoError(t, err)
+	assert.Equal(t, "ALTER KEYSPACE tenant_42 WITH replication = {'class': 'NetworkTopologyStrategy', 'dc1': '5'};", sql)
+	sql, err = AlterKeyspaceStatement("tenant_42", Replication{}, WithDurableWrites(true))
+	assert.NoError(t, err)
+	assert.Equal(t, "ALTER KEYSPACE tenant_42 WITH durable_writes = true;", sql)
+
+	sql, err = DropKeyspaceStatement("tenant_42")
+	assert.NoError(t, err)
+	assert.Equal(t, "DROP KEYSPACE IF EXISTS tenant_42;", sql)
+}
+
+func Test_InvalidKeyspaceStatements(t *testing.T) {
+	_, err := CreateKeyspaceStatement("cqlorm", Replication{})
+	assert.Error(t, err)
+	_, err = CreateKeyspaceStatement("cqlorm", SimpleStrategy(0))
+	assert.Error(t, err)
+	_, err = CreateKeyspaceStatement("cqlorm", NetworkTopologyStrategy(nil))
+	assert.Error(t, err)
+	_, err = CreateKeyspaceStatement("cqlorm", Replication{Class: "LocalStrategy"})
+	assert.Error(t, err)
+	_, err = CreateKeyspaceStatement("bad-name", SimpleStrategy(1))
+	assert.Error(t, err)
+	_, err = AlterKeyspaceStatement("cqlorm", Replication{})
+	assert.Error(t, err)
+	_, err = DropKeyspaceStatement("cqlorm; DROP TABLE x")
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package nosqlorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// InsertIfNotExists Insert the row only if it does not exist yet.
+// If the write was not applied, the existing row is returned.
+func (ctx *cqlOrm[T]) InsertIfNotExists(obj T) (bool, T, error) {
+	return ctx.InsertIfNotExistsCtx(context.Background(), obj)
+}
+
+// InsertIfNotExistsCtx is the same as InsertIfNotExists, the query is bound to the given context.
+func (ctx *cqlOrm[T]) InsertIfNotExistsCtx(c context.Context, obj T) (bool, T, error) {
+	sql, sqlValues, err := buildInsertStatement(reflect.ValueOf(obj), statementOptions{writeOptions: ctx.opts.writeOptions, ifNotExists: true, table: ctx.table()})
+	if err != nil {
+		var current T
+		return false, current, err
+	}
+	return ctx.execCAS(c, sql, sqlValues)
+}
+
+// UpdateIf Update the row only if all conditions are met.
+// If the write was not applied, the current values of the condition columns are returned.
+func (ctx *cqlOrm[T]) UpdateIf(obj T, conditions ...Condition) (bool, T, error) {
+	return ctx.UpdateIfCtx(context.Background(), obj, conditions...)
+}
+
+// UpdateIfCtx is the same as UpdateIf, the query is bound to the given context.
+func (ctx *cqlOrm[T]) UpdateIfCtx(c context.Context, obj T, conditions ...Condition) (bool, T, error) {
+	var current T
+	if len(conditions) == 0 {
+		return false, current, errors.New("UpdateIf requires at least one condition")
+	}
+	sql, sqlValues, err := buildUpdateStatement(reflect.ValueOf(obj), statementOptions{writeOptions: ctx.opts.writeOptions, conditions: conditions, table: ctx.table()})
+	if err != nil {
+		return false, current, err
+	}
+	return ctx.execCAS(c, sql, sqlValues)
+}
+
+// DeleteIfExists Delete the row only if it exists.
+func (ctx *cqlOrm[T]) DeleteIfExists(obj T) (bool, error) {
+	return ctx.DeleteIfExistsCtx(context.Background(), obj)
+}
+
+// DeleteIfExistsCtx is the same as DeleteIfExists, the query is bound to the given context.
+func (ctx *cqlOrm[T]) DeleteIfExistsCtx(c context.Context, obj T) (bool, error) {
+	sql, sqlValues, err := buildDeleteStatement(reflect.ValueOf(obj), statementOptions{ifExists: true, table: ctx.table()})
+	if err != nil {
+		return false, err
+	}
+	applied, _, err := ctx.execCAS(c, sql, sqlValues)
+	return applied, err
+}
+
+// Run a lightweight transaction and decode the current row when it was not applied.
+func (ctx *cqlOrm[T]) execCAS(c context.Context, sql string, sqlValues []interface{}) (bool, T, error) {
+	var current T
+	row := make(map[string]interface{})
+	applied, err := ctx.sess.Query(sql, sqlValues...).
+		SerialConsistency(ctx.opts.serialConsistency).
+		WithContext(c).
+		MapScanCAS(row)
+	if err != nil || applied {
+		return applied, current, err
+	}
+	err = decodeRowMap(row, reflect.ValueOf(&current).Elem())
+	return applied, current, err
+}
+
+// Decode a row fetched by MapScan into a model object, columns not belonging to the model are ignored.
+func decodeRowMap(row map[string]interface{}, val reflect.Value) error {
+	schema, _, err := loadTableSchema(val.Type(), "")
+	if err != nil {
+		return err
+	}
+	for i, fieldName := range schema.fields {
+		if fieldName == "-" {
+			continue
+		}
+		colVal, ok := row[fieldName]
+		if !ok || colVal == nil {
+			continue
+		}
+		if err := setFieldValue(val.Field(i), reflect.ValueOf(colVal)); err != nil {
+			return errors.New(fmt.Sprintf("Decode column %s failed: %s", fieldName, err.Error()))
+		}
+	}
+	return nil
+}
+
+// Assign a value scanned by gocql to a struct field, converting numbers and list elements when needed.
+func setFieldValue(field reflect.Value, colVal reflect.Value) error {
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setFieldValue(ptr.Elem(), colVal); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+	switch {
+	case colVal.Type().AssignableTo(field.Type()):
+		field.Set(colVal)
+	case field.Kind() == reflect.Slice && colVal.Kind() == reflect.Slice:
+		list := reflect.MakeSlice(field.Type(), colVal.Len(), colVal.Len())
+		for i := 0; i < colVal.Len(); i++ {
+			if err := setFieldValue(list.Index(i), colVal.Index(i)); err != nil {
+				return err
+			}
+		}
+		field.Set(list)
+	case isNumberKind(colVal.Kind()) && isNumberKind(field.Kind()):
+		field.Set(colVal.Convert(field.Type()))
+	default:
+		return errors.New(fmt.Sprintf("could not assign %s to %s", colVal.Type(), field.Type()))
+	}
+	return nil
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
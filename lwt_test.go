@@ -0,0 +1,69 @@
+package nosqlorm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_BuildLWTStatements(t *testing.T) {
+	_, err := NewCqlOrm[testPerson](nil)
+	assert.NoError(t, err)
+	person := testPerson{Name: "tony", Age: 30, Address: GetPointer("street")}
+
+	sql, _, err := buildInsertStatement(reflect.ValueOf(person), statementOptions{ifNotExists: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO testperson (name,age,address) VALUES (?,?,?) IF NOT EXISTS;", sql)
+
+	sql, values, err := buildUpdateStatement(reflect.ValueOf(person), statementOptions{conditions: []Condition{Eq("address", "old street")}})
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE testperson SET address=? WHERE name=? AND age=? IF address=?;", sql)
+	assert.Equal(t, []interface{}{"street", "tony", int8(30), "old street"}, values)
+
+	sql, _, err = buildDeleteStatement(reflect.ValueOf(person), statementOptions{ifExists: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM testperson WHERE name=? AND age=? IF EXISTS;", sql)
+
+	_, _, err = buildUpdateStatement(reflect.ValueOf(person), statementOptions{conditions: []Condition{Eq("unknown", 1)}})
+	assert.Error(t, err)
+	_, _, err = buildUpdateStatement(reflect.ValueOf(person), statementOptions{conditions: []Condition{{Column: "address", Op: "LIKE"}}})
+	assert.Error(t, err)
+}
+
+func Test_LWTWriteOptions(t *testing.T) {
+	orm, err := NewCqlOrm[testPerson](nil, WithDefaultWriteOptions(WithTTL(time.Minute)))
+	assert.NoError(t, err)
+	person := testPerson{Name: "tony", Age: 30, Address: GetPointer("street")}
+
+	// Default write options of the ORM object apply to lightweight transactions as well
+	sql, values, err := buildInsertStatement(reflect.ValueOf(person), statementOptions{writeOptions: orm.opts.writeOptions, ifNotExists: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO testperson (name,age,address) VALUES (?,?,?) IF NOT EXISTS USING TTL ?;", sql)
+	assert.Equal(t, []interface{}{"tony", int8(30), "street", 60}, values)
+	sql, values, err = buildUpdateStatement(reflect.ValueOf(person), statementOptions{writeOptions: orm.opts.writeOptions, conditions: []Condition{Eq("address", "old street")}})
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE testperson USING TTL ? SET address=? WHERE name=? AND age=? IF address=?;", sql)
+	assert.Equal(t, []interface{}{60, "street", "tony", int8(30), "old street"}, values)
+
+	_, _, err = buildInsertStatement(reflect.ValueOf(person), statementOptions{writeOptions: writeOptions{}.with([]WriteOption{WithTimestamp(time.Now())}), ifNotExists: true})
+	assert.EqualError(t, err, "Custom write timestamps are not allowed on lightweight transactions")
+}
+
+func Test_DecodeRowMap(t *testing.T) {
+	_, err := NewCqlOrm[testPerson](nil)
+	assert.NoError(t, err)
+
+	var person testPerson
+	row := map[string]interface{}{"name": "tony", "age": int8(30), "address": "street", "unknown": 1}
+	assert.NoError(t, decodeRowMap(row, reflect.ValueOf(&person).Elem()))
+	assert.Equal(t, testPerson{Name: "tony", Age: 30, Address: GetPointer("street")}, person)
+
+	type list struct {
+		Numbers []int `json:"numbers"`
+	}
+	var numbers list
+	assert.NoError(t, setFieldValue(reflect.ValueOf(&numbers).Elem().Field(0), reflect.ValueOf([]int64{1, 2})))
+	assert.Equal(t, []int{1, 2}, numbers.Numbers)
+	assert.Error(t, setFieldValue(reflect.ValueOf(&person).Elem().Field(0), reflect.ValueOf(int64(1))))
+}
@@ -0,0 +1,55 @@
+package nosqlorm
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+)
+
+var mapperCache sync.Map
+
+// ModelMapper holds generated, reflection-free accessors of a model type, see cmd/nosqlorm-gen.
+// Generated code registers it in an init function, and NewCqlOrm picks it up automatically.
+type ModelMapper[T any] struct {
+	// Column names in field order, "-" for ignored fields
+	Columns []string
+	// Bind values of all fields in field order, nil for nil pointers and ignored fields
+	Values func(obj T) []interface{}
+	// Scan destinations of the given columns
+	Pointers func(obj *T, columns []string) []interface{}
+}
+
+// Type erased accessors stored in the table schema
+type modelMapper struct {
+	columns  []string
+	values   func(obj interface{}) []interface{}
+	pointers func(obj interface{}, columns []string) []interface{}
+}
+
+// RegisterMapper Register generated accessors of a model type, the reflection based path is used for models without one.
+func RegisterMapper[T any](mapper ModelMapper[T]) {
+	var t T
+	typName := reflect.TypeOf(t).String()
+	erased := &modelMapper{
+		columns: mapper.Columns,
+		values: func(obj interface{}) []interface{} {
+			return mapper.Values(obj.(T))
+		},
+		pointers: func(obj interface{}, columns []string) []interface{} {
+			return mapper.Pointers(obj.(*T), columns)
+		},
+	}
+	mapperCache.Store(typName, erased)
+
+	// Attach to the schema if the model was registered before the mapper
+	if schema, ok := modelCache.Load(typName); ok && erased.matches(schema.(tableSchema)) {
+		cached := schema.(tableSchema)
+		cached.mapper = erased
+		modelCache.Store(typName, cached)
+	}
+}
+
+// Generated code out of sync with the struct is ignored, so that the reflection path is used instead
+func (mapper *modelMapper) matches(schema tableSchema) bool {
+	return slices.Equal(mapper.columns, schema.fields)
+}
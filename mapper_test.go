@@ -0,0 +1,76 @@
+package nosqlorm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type testMappedPerson struct {
+	Name    string  `json:"name" cql:"pk"`
+	Age     int8    `json:"age" cql:"ck"`
+	Address *string `json:"address"`
+}
+
+type testStaleMappedPerson struct {
+	Name string `json:"name" cql:"pk"`
+	Age  int8   `json:"age"`
+}
+
+func Test_RegisterMapper(t *testing.T) {
+	calls := 0
+	RegisterMapper(ModelMapper[testMappedPerson]{
+		Columns: []string{"name", "age", "address"},
+		Values: func(obj testMappedPerson) []interface{} {
+			calls++
+			values := make([]interface{}, 3)
+			values[0] = obj.Name
+			values[1] = obj.Age
+			if obj.Address != nil {
+				values[2] = *obj.Address
+			}
+			return values
+		},
+		Pointers: func(obj *testMappedPerson, columns []string) []interface{} {
+			calls++
+			pointers := make([]interface{}, 0, len(columns))
+			for _, column := range columns {
+				switch column {
+				case "name":
+					pointers = append(pointers, &obj.Name)
+				case "age":
+					pointers = append(pointers, &obj.Age)
+				case "address":
+					pointers = append(pointers, &obj.Address)
+				}
+			}
+			return pointers
+		},
+	})
+	_, err := NewCqlOrm[testMappedPerson](nil)
+	assert.NoError(t, err)
+	schema, _, err := loadTableSchema(reflect.TypeOf(testMappedPerson{}), "")
+	assert.NoError(t, err)
+	assert.NotNil(t, schema.mapper)
+
+	sql, values, err := buildInsertStatement(reflect.ValueOf(testMappedPerson{Name: "tony", Age: 30}), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO testmappedperson (name,age) VALUES (?,?);", sql)
+	assert.Equal(t, []interface{}{"tony", int8(30)}, values)
+
+	var obj testMappedPerson
+	pointers := fieldPointers(&obj, []string{"address", "name"}, schema)
+	assert.Equal(t, []interface{}{&obj.Address, &obj.Name}, pointers)
+	assert.Equal(t, 2, calls)
+
+	// Generated code out of sync with the struct falls back to reflection
+	RegisterMapper(ModelMapper[testStaleMappedPerson]{Columns: []string{"name"}})
+	_, err = NewCqlOrm[testStaleMappedPerson](nil)
+	assert.NoError(t, err)
+	schema, _, err = loadTableSchema(reflect.TypeOf(testStaleMappedPerson{}), "")
+	assert.NoError(t, err)
+	assert.Nil(t, schema.mapper)
+	_, values, err = buildInsertStatement(reflect.ValueOf(testStaleMappedPerson{Name: "tony", Age: 30}), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"tony", int8(30)}, values)
+}
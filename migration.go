@@ -0,0 +1,389 @@
+package nosqlorm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/gocql/gocql"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultMigrationTable = "schema_migrations"
+
+// How long releasing the lock may take after the migration context was cancelled
+const lockReleaseTimeout = 10 * time.Second
+
+var ErrMigrationLocked = errors.New("migrations are locked by another runner")
+
+// MigrationLockError is returned by Migrate when another runner holds the migration lock, it wraps ErrMigrationLocked.
+type MigrationLockError struct {
+	Owner      string
+	AcquiredAt time.Time
+}
+
+func (e *MigrationLockError) Error() string {
+	return fmt.Sprintf("%s: owner %s since %s", ErrMigrationLocked.Error(), e.Owner, e.AcquiredAt.Format(time.RFC3339))
+}
+
+func (e *MigrationLockError) Unwrap() error {
+	return ErrMigrationLocked
+}
+
+// Migration is a versioned schema change, applied once in version order.
+// Either CQL statements or a Go function such as a data backfill could be given, CQL statements run first.
+type Migration struct {
+	Version     int64
+	Description string
+	CQL         []string
+	Up          func(ctx context.Context, sess *gocql.Session) error
+}
+
+// CQLMigration Create a migration running CQL statements in order
+func CQLMigration(version int64, description string, statements ...string) Migration {
+	return Migration{Version: version, Description: description, CQL: statements}
+}
+
+// GoMigration Create a migration running a Go function, such as a data backfill
+func GoMigration(version int64, description string, up func(ctx context.Context, sess *gocql.Session) error) Migration {
+	return Migration{Version: version, Description: description, Up: up}
+}
+
+// ModelMigration Create a migration creating or updating the tables of models by CreateCassandraTables,
+// the context is checked before and after each table so that a cancelled migration stops between tables.
+func ModelMigration(version int64, description string, models ...interface{}) Migration {
+	return GoMigration(version, description, func(ctx context.Context, sess *gocql.Session) error {
+		for _, model := range models {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := CreateCassandraTables(sess, model); err != nil {
+				return err
+			}
+		}
+		return ctx.Err()
+	})
+}
+
+// Checksum of the CQL statements, to tell whether an applied migration was modified afterwards
+func (m Migration) checksum() string {
+	if len(m.CQL) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join(m.CQL, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// MigrationStatus State of a registered migration
+type MigrationStatus struct {
+	Version     int64
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+	// Applied CQL statements differ from the registered ones
+	Modified bool
+}
+
+type appliedMigration struct {
+	appliedAt time.Time
+	checksum  string
+}
+
+// Migrator applies registered migrations in version order and records them in a tracking table,
+// concurrent runners are coordinated by a lightweight transaction lock.
+type Migrator struct {
+	sess       *gocql.Session
+	migrations []Migration
+	table      string
+	lockTTL    time.Duration
+	owner      string
+	acquiredAt time.Time
+}
+
+// MigratorOption Customize a Migrator created by NewMigrator
+type MigratorOption func(*Migrator)
+
+// WithMigrationTable Set the tracking table, default is schema_migrations. The lock table has the suffix _lock.
+func WithMigrationTable(table string) MigratorOption {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+// WithLockTTL Set how long the lock is kept if a runner dies without releasing it, default is 10 minutes.
+// A running migration renews the lock every third of the TTL, the TTL must be at least one second.
+func WithLockTTL(ttl time.Duration) MigratorOption {
+	return func(m *Migrator) {
+		m.lockTTL = ttl
+	}
+}
+
+// NewMigrator Create a migration runner on the keyspace of the session
+func NewMigrator(sess *gocql.Session, options ...MigratorOption) *Migrator {
+	m := &Migrator{
+		sess:    sess,
+		table:   defaultMigrationTable,
+		lockTTL: 10 * time.Minute,
+		owner:   gocql.TimeUUID().String(),
+	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// Register Add migrations, versions must be positive and unique
+func (m *Migrator) Register(migrations ...Migration) error {
+	for _, migration := range migrations {
+		if migration.Version <= 0 {
+			return errors.New(fmt.Sprintf("Invalid migration version %d: must be positive", migration.Version))
+		}
+		if len(migration.CQL) == 0 && migration.Up == nil {
+			return errors.New(fmt.Sprintf("Invalid migration %d: no CQL statement or Go function", migration.Version))
+		}
+		for _, existing := range m.migrations {
+			if existing.Version == migration.Version {
+				return errors.New(fmt.Sprintf("Invalid migration %d: duplicated version", migration.Version))
+			}
+		}
+		m.migrations = append(m.migrations, migration)
+	}
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+	return nil
+}
+
+// Status Report registered migrations in version order and whether they were applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	applied, err := m.existingMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return migrationStatus(m.migrations, applied), nil
+}
+
+// DryRun Report the migrations Migrate would apply without running them, nothing is written
+func (m *Migrator) DryRun(ctx context.Context) ([]Migration, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	applied, err := m.existingMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pendingMigrations(m.migrations, applied), nil
+}
+
+// Migrate Apply pending migrations in version order and return the applied ones,
+// it stops at the first failure and returns a *MigrationLockError if another runner is migrating.
+// The lock is renewed while migrating, the running migration is cancelled if the lock is lost.
+func (m *Migrator) Migrate(ctx context.Context) (done []Migration, err error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	if err := m.createTables(ctx); err != nil {
+		return nil, err
+	}
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+	runCtx, lost := context.WithCancelCause(ctx)
+	stopRenewal := m.keepLock(runCtx, lost)
+	defer func() {
+		stopRenewal()
+		lost(nil)
+		if unlockErr := m.unlock(ctx); unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+
+	// Read after locking, another runner may have migrated in the meantime
+	applied, err := m.appliedMigrations(runCtx)
+	if err != nil {
+		return nil, err
+	}
+	done = make([]Migration, 0)
+	for _, migration := range pendingMigrations(m.migrations, applied) {
+		if err := m.apply(runCtx, migration); err != nil {
+			// Report the lost lock rather than the cancellation it caused
+			if cause := context.Cause(runCtx); cause != nil && ctx.Err() == nil {
+				err = cause
+			}
+			return done, errors.New(fmt.Sprintf("Migration %d %s failed: %s", migration.Version, migration.Description, err.Error()))
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Table names are put into CQL, so that they must be plain identifiers
+func (m *Migrator) validate() error {
+	if !identifierPattern.MatchString(m.table) {
+		return errors.New(fmt.Sprintf("Invalid migration table %s", m.table))
+	}
+	if m.lockTTL < time.Second {
+		return errors.New(fmt.Sprintf("Invalid migration lock TTL %s: must be at least one second", m.lockTTL))
+	}
+	return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	for _, statement := range migration.CQL {
+		if err := m.sess.Query(statement).WithContext(ctx).Exec(); err != nil {
+			return err
+		}
+	}
+	if migration.Up != nil {
+		if err := migration.Up(ctx, m.sess); err != nil {
+			return err
+		}
+	}
+	return m.sess.Query(fmt.Sprintf("INSERT INTO %s (version, description, checksum, applied_at) VALUES (?, ?, ?, ?);", m.table),
+		migration.Version, migration.Description, migration.checksum(), time.Now()).WithContext(ctx).Exec()
+}
+
+func (m *Migrator) createTables(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version bigint, description text, checksum text, applied_at timestamp, PRIMARY KEY (version));", m.table),
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s_lock (name text, owner text, acquired_at timestamp, PRIMARY KEY (name));", m.table),
+	}
+	for _, statement := range statements {
+		if err := m.sess.Query(statement).WithContext(ctx).Exec(); err != nil {
+			return errors.New(fmt.Sprintf("Create migration tables failed: %s", err.Error()))
+		}
+	}
+	return nil
+}
+
+// Applied migrations of a tracking table which might not exist yet, used by read-only reports
+func (m *Migrator) existingMigrations(ctx context.Context) (map[int64]appliedMigration, error) {
+	columns, err := readTableColumns(m.sess, m.sess.Query("").Keyspace(), m.table)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return map[int64]appliedMigration{}, nil
+	}
+	return m.appliedMigrations(ctx)
+}
+
+func (m *Migrator) appliedMigrations(ctx context.Context) (map[int64]appliedMigration, error) {
+	iter := m.sess.Query(fmt.Sprintf("SELECT version, checksum, applied_at FROM %s;", m.table)).WithContext(ctx).Iter()
+	applied := make(map[int64]appliedMigration)
+	var version int64
+	var record appliedMigration
+	for iter.Scan(&version, &record.checksum, &record.appliedAt) {
+		applied[version] = record
+	}
+	if err := iter.Close(); err != nil {
+		return nil, errors.New(fmt.Sprintf("Read applied migrations failed: %s", err.Error()))
+	}
+	return applied, nil
+}
+
+func (m *Migrator) lock(ctx context.Context) error {
+	m.acquiredAt = time.Now()
+	row := make(map[string]interface{})
+	applied, err := m.sess.Query(fmt.Sprintf("INSERT INTO %s_lock (name, owner, acquired_at) VALUES ('migrate', ?, ?) IF NOT EXISTS USING TTL ?;", m.table),
+		m.owner, m.acquiredAt, int(m.lockTTL.Seconds())).WithContext(ctx).SerialConsistency(gocql.Serial).MapScanCAS(row)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Acquire migration lock failed: %s", err.Error()))
+	}
+	if !applied {
+		return lockError(row)
+	}
+	return nil
+}
+
+func lockError(row map[string]interface{}) *MigrationLockError {
+	lockErr := &MigrationLockError{}
+	lockErr.Owner, _ = row["owner"].(string)
+	lockErr.AcquiredAt, _ = row["acquired_at"].(time.Time)
+	return lockErr
+}
+
+// Renew the lock every third of its TTL until stopped, lost is called with the reason if the lock could not be renewed
+func (m *Migrator) keepLock(ctx context.Context, lost context.CancelCauseFunc) (stop func()) {
+	stopping := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(m.lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopping:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := m.renewLock(ctx); err != nil {
+					lost(err)
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stopping)
+		<-stopped
+	}
+}
+
+func (m *Migrator) renewLock(ctx context.Context) error {
+	row := make(map[string]interface{})
+	applied, err := m.sess.Query(fmt.Sprintf("UPDATE %s_lock USING TTL ? SET owner = ?, acquired_at = ? WHERE name = 'migrate' IF owner = ?;", m.table),
+		int(m.lockTTL.Seconds()), m.owner, m.acquiredAt, m.owner).WithContext(ctx).SerialConsistency(gocql.Serial).MapScanCAS(row)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Renew migration lock failed: %s", err.Error()))
+	}
+	if !applied {
+		return lockError(row)
+	}
+	return nil
+}
+
+// Release the lock even if the context of the run was cancelled, otherwise it is held until its TTL expires
+func (m *Migrator) unlock(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lockReleaseTimeout)
+	defer cancel()
+	row := make(map[string]interface{})
+	_, err := m.sess.Query(fmt.Sprintf("DELETE FROM %s_lock WHERE name = 'migrate' IF owner = ?;", m.table), m.owner).
+		WithContext(ctx).SerialConsistency(gocql.Serial).MapScanCAS(row)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Release migration lock failed: %s", err.Error()))
+	}
+	return nil
+}
+
+func pendingMigrations(migrations []Migration, applied map[int64]appliedMigration) []Migration {
+	pending := make([]Migration, 0)
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+func migrationStatus(migrations []Migration, applied map[int64]appliedMigration) []MigrationStatus {
+	status := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		record, ok := applied[migration.Version]
+		status = append(status, MigrationStatus{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Applied:     ok,
+			AppliedAt:   record.appliedAt,
+			Modified:    ok && record.checksum != migration.checksum(),
+		})
+	}
+	return status
+}
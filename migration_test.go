@@ -0,0 +1,78 @@
+package nosqlorm
+
+import (
+	"context"
+	"errors"
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_MigratorRegister(t *testing.T) {
+	m := NewMigrator(nil)
+	assert.NoError(t, m.Register(
+		CQLMigration(2, "add index", "CREATE INDEX IF NOT EXISTS ON person (address);"),
+		ModelMigration(1, "create tables", testPerson{}),
+	))
+	assert.Equal(t, int64(1), m.migrations[0].Version)
+	assert.Equal(t, int64(2), m.migrations[1].Version)
+
+	assert.Error(t, m.Register(CQLMigration(2, "duplicated", "SELECT now() FROM system.local;")))
+	assert.Error(t, m.Register(CQLMigration(0, "invalid version", "SELECT now() FROM system.local;")))
+	assert.Error(t, m.Register(Migration{Version: 3, Description: "nothing to do"}))
+	assert.NoError(t, m.Register(GoMigration(3, "backfill", func(ctx context.Context, sess *gocql.Session) error {
+		return nil
+	})))
+	assert.Len(t, m.migrations, 3)
+}
+
+func Test_ModelMigrationContext(t *testing.T) {
+	// A cancelled migration returns before using the session
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ModelMigration(1, "create tables", testPerson{}, testPageView{}).Up(ctx, nil)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func Test_MigrationStatus(t *testing.T) {
+	addIndex := CQLMigration(2, "add index", "CREATE INDEX IF NOT EXISTS ON person (address);")
+	migrations := []Migration{
+		ModelMigration(1, "create tables", testPerson{}),
+		addIndex,
+		CQLMigration(3, "add column", "ALTER TABLE person ADD nickname text;"),
+	}
+	appliedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	applied := map[int64]appliedMigration{
+		1: {appliedAt: appliedAt},
+		2: {appliedAt: appliedAt, checksum: addIndex.checksum()},
+	}
+
+	pending := pendingMigrations(migrations, applied)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, int64(3), pending[0].Version)
+
+	status := migrationStatus(migrations, applied)
+	assert.Equal(t, MigrationStatus{Version: 1, Description: "create tables", Applied: true, AppliedAt: appliedAt}, status[0])
+	assert.False(t, status[1].Modified)
+	assert.False(t, status[2].Applied)
+
+	// Applied statements were changed afterwards
+	applied[2] = appliedMigration{appliedAt: appliedAt, checksum: "changed"}
+	assert.True(t, migrationStatus(migrations, applied)[1].Modified)
+}
+
+func Test_MigrationLockError(t *testing.T) {
+	err := error(&MigrationLockError{Owner: "runner-1", AcquiredAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)})
+	assert.True(t, errors.Is(err, ErrMigrationLocked))
+	assert.Equal(t, "migrations are locked by another runner: owner runner-1 since 2024-01-02T03:04:05Z", err.Error())
+}
+
+func Test_MigratorValidation(t *testing.T) {
+	_, err := NewMigrator(nil, WithMigrationTable("migrations; DROP TABLE person")).Status(context.Background())
+	assert.EqualError(t, err, "Invalid migration table migrations; DROP TABLE person")
+	_, err = NewMigrator(nil, WithMigrationTable("1migrations")).DryRun(context.Background())
+	assert.Error(t, err)
+	_, err = NewMigrator(nil, WithLockTTL(500*time.Millisecond)).Migrate(context.Background())
+	assert.EqualError(t, err, "Invalid migration lock TTL 500ms: must be at least one second")
+}
@@ -1,11 +1,18 @@
 package nosqlorm
 
 import (
+	"context"
+	"errors"
+	"github.com/gocql/gocql"
 	"github.com/stretchr/testify/assert"
+	"strconv"
 	"sync"
 	"testing"
 )
 
+// Returned by mock calls which were not expected, the test has been failed already
+var errUnexpectedCall = errors.New("unexpected call of the mock")
+
 type MockSession struct {
 	Test         *testing.T
 	Expectations []interface{}
@@ -15,15 +22,91 @@ type MockSession struct {
 
 type expect[T interface{}] struct {
 	ExpectInput []T
-	returnObj   []T // For Select only
+	returnObj   []T           // For Select only
+	options     *writeOptions // For Insert and Update only, nil means not checked
+	errorObj    error
+}
+
+type pageExpect[T interface{}] struct {
+	expectInput T
+	pageSize    int
+	cursor      []byte
+	returnObj   []T
+	nextCursor  []byte
+	errorObj    error
+}
+
+type fieldsExpect[T interface{}] struct {
+	expectInput T
+	mask        FieldMask
+	returnObj   []T
+	errorObj    error
+}
+
+type streamExpect[T interface{}] struct {
+	expectInput T
+	returnObj   []T
+	errorObj    error
+}
+
+type findExpect[T interface{}] struct {
+	spec      QuerySpec
+	returnObj []T
+	errorObj  error
+}
+
+type lwtExpect[T interface{}] struct {
+	op          Operation
+	expectInput T
+	conditions  []Condition
+	applied     bool
+	current     T
 	errorObj    error
 }
 
+// BatchEntry is one write statement expected in a mocked batch.
+type BatchEntry[T interface{}] struct {
+	Op  Operation
+	Obj T
+	// Write options of Insert and Update, they are only asserted if the expected entry has some
+	Options []WriteOption
+}
+
+type batchExpect[T interface{}] struct {
+	batchType gocql.BatchType
+	entries   []BatchEntry[T]
+	errorObj  error
+}
+
+type MockBatch[T interface{}] struct {
+	table     *MockTable[T]
+	batchType gocql.BatchType
+	entries   []BatchEntry[T]
+}
+
+// UnitOfWorkEntry is one write expected in a mocked unit of work.
+type UnitOfWorkEntry struct {
+	Op  Operation
+	Obj interface{}
+}
+
+type unitOfWorkExpect struct {
+	entries  []UnitOfWorkEntry
+	errorObj error
+}
+
+type MockUnitOfWork struct {
+	sess    *MockSession
+	entries []UnitOfWorkEntry
+}
+
 type MockTable[T interface{}] struct {
 	sess *MockSession
 	t    *testing.T
 }
 
+var _ NoSqlView[struct{}] = (*MockTable[struct{}])(nil)
+
 func NewMockSession(t *testing.T) *MockSession {
 	return &MockSession{Test: t, Expectations: make([]interface{}, 0), idx: 0}
 }
@@ -34,48 +117,69 @@ func (m *MockSession) AddIdx() {
 	m.idx++
 }
 
+// Take the next expectation of the session, a missing expectation or one of another kind fails the test
+func nextExpectation[E interface{}](sess *MockSession, kind string) (E, bool) {
+	sess.lock.Lock()
+	defer sess.lock.Unlock()
+	idx := sess.idx
+	sess.idx++
+	var expectation E
+	if idx >= len(sess.Expectations) {
+		sess.Test.Error("Unexpected " + kind)
+		return expectation, false
+	}
+	expectation, ok := sess.Expectations[idx].(E)
+	if !ok {
+		sess.Test.Errorf("Unexpected %s, the next expectation is %T", kind, sess.Expectations[idx])
+		return expectation, false
+	}
+	return expectation, true
+}
+
 func NewMockTable[T interface{}](sess *MockSession) *MockTable[T] {
 	return &MockTable[T]{sess: sess, t: sess.Test}
 }
 
 func (m *MockTable[T]) Select(obj T) ([]T, error) {
-	idx := m.sess.idx
-	defer m.sess.AddIdx()
-	if idx >= len(m.sess.Expectations) {
-		m.t.Error("Unexpected query")
+	expectation, ok := nextExpectation[expect[T]](m.sess, "query")
+	if !ok {
+		return nil, errUnexpectedCall
 	}
-	assert.Equal(m.t, obj, m.sess.Expectations[idx].(expect[T]).ExpectInput[0])
-	return m.sess.Expectations[idx].(expect[T]).returnObj, nil
+	assert.Equal(m.t, obj, expectation.ExpectInput[0])
+	return expectation.returnObj, nil
 }
 
-func (m *MockTable[T]) Insert(obj T) error {
-	idx := m.sess.idx
-	defer m.sess.AddIdx()
-	if idx >= len(m.sess.Expectations) {
-		m.t.Error("Unexpected query")
-	}
-	assert.Equal(m.t, obj, m.sess.Expectations[idx].(expect[T]).ExpectInput[0])
-	return m.sess.Expectations[idx].(expect[T]).errorObj
+func (m *MockTable[T]) Insert(obj T, options ...WriteOption) error {
+	return m.matchWrite(obj, options)
 }
 
-func (m *MockTable[T]) Update(obj T) error {
-	idx := m.sess.idx
-	defer m.sess.AddIdx()
-	if idx >= len(m.sess.Expectations) {
-		m.t.Error("Unexpected query")
-	}
-	assert.Equal(m.t, obj, m.sess.Expectations[idx].(expect[T]).ExpectInput[0])
-	return m.sess.Expectations[idx].(expect[T]).errorObj
+func (m *MockTable[T]) Update(obj T, options ...WriteOption) error {
+	return m.matchWrite(obj, options)
 }
 
 func (m *MockTable[T]) Delete(obj T) error {
-	idx := m.sess.idx
-	defer m.sess.AddIdx()
-	if idx >= len(m.sess.Expectations) {
-		m.t.Error("Unexpected query")
+	expectation, ok := nextExpectation[expect[T]](m.sess, "query")
+	if !ok {
+		return errUnexpectedCall
 	}
-	assert.Equal(m.t, obj, m.sess.Expectations[idx].(expect[T]).ExpectInput[0])
-	return m.sess.Expectations[idx].(expect[T]).errorObj
+	assert.Equal(m.t, obj, expectation.ExpectInput[0])
+	return expectation.errorObj
+}
+
+func (m *MockTable[T]) SelectCtx(_ context.Context, obj T) ([]T, error) {
+	return m.Select(obj)
+}
+
+func (m *MockTable[T]) InsertCtx(_ context.Context, obj T, options ...WriteOption) error {
+	return m.Insert(obj, options...)
+}
+
+func (m *MockTable[T]) UpdateCtx(_ context.Context, obj T, options ...WriteOption) error {
+	return m.Update(obj, options...)
+}
+
+func (m *MockTable[T]) DeleteCtx(_ context.Context, obj T) error {
+	return m.Delete(obj)
 }
 
 func (m *MockTable[T]) AddSelectExpectation(input T, objs []T) {
@@ -95,3 +199,332 @@ func (m *MockTable[T]) AddOtherExpectation(input T, err error) {
 	}
 	m.sess.Expectations = append(m.sess.Expectations, newExpect)
 }
+
+// AddWriteExpectation Expect an Insert or Update of input with the given write options, TTL, timestamp and null policy are all asserted.
+func (m *MockTable[T]) AddWriteExpectation(input T, options []WriteOption, err error) {
+	opts := writeOptions{}.with(options)
+	newExpect := expect[T]{
+		ExpectInput: []T{input},
+		options:     &opts,
+		errorObj:    err,
+	}
+	m.sess.Expectations = append(m.sess.Expectations, newExpect)
+}
+
+func (m *MockTable[T]) matchWrite(obj T, options []WriteOption) error {
+	expectation, ok := nextExpectation[expect[T]](m.sess, "query")
+	if !ok {
+		return errUnexpectedCall
+	}
+	assert.Equal(m.t, obj, expectation.ExpectInput[0])
+	if expectation.options != nil {
+		assert.Equal(m.t, *expectation.options, writeOptions{}.with(options))
+	}
+	return expectation.errorObj
+}
+
+func (m *MockTable[T]) NewBatch(batchType gocql.BatchType) Batch[T] {
+	return &MockBatch[T]{table: m, batchType: batchType, entries: make([]BatchEntry[T], 0)}
+}
+
+func (m *MockTable[T]) AddBatchExpectation(batchType gocql.BatchType, entries []BatchEntry[T], err error) {
+	newExpect := batchExpect[T]{
+		batchType: batchType,
+		entries:   entries,
+		errorObj:  err,
+	}
+	m.sess.Expectations = append(m.sess.Expectations, newExpect)
+}
+
+func (b *MockBatch[T]) Insert(obj T, options ...WriteOption) error {
+	b.entries = append(b.entries, BatchEntry[T]{Op: InsertOp, Obj: obj, Options: options})
+	return nil
+}
+
+func (b *MockBatch[T]) Update(obj T, options ...WriteOption) error {
+	b.entries = append(b.entries, BatchEntry[T]{Op: UpdateOp, Obj: obj, Options: options})
+	return nil
+}
+
+func (b *MockBatch[T]) Delete(obj T) error {
+	b.entries = append(b.entries, BatchEntry[T]{Op: DeleteOp, Obj: obj})
+	return nil
+}
+
+func (b *MockBatch[T]) Exec() error {
+	m := b.table
+	expectation, ok := nextExpectation[batchExpect[T]](m.sess, "batch")
+	if !ok {
+		return errUnexpectedCall
+	}
+	assert.Equal(m.t, expectation.batchType, b.batchType)
+	// Options are functions, they are compared by the write options they resolve to like matchWrite does
+	expected := make([]BatchEntry[T], 0, len(expectation.entries))
+	for _, entry := range expectation.entries {
+		expected = append(expected, BatchEntry[T]{Op: entry.Op, Obj: entry.Obj})
+	}
+	actual := make([]BatchEntry[T], 0, len(b.entries))
+	for _, entry := range b.entries {
+		actual = append(actual, BatchEntry[T]{Op: entry.Op, Obj: entry.Obj})
+	}
+	if assert.Equal(m.t, expected, actual) {
+		for i, entry := range expectation.entries {
+			if entry.Options != nil {
+				assert.Equal(m.t, writeOptions{}.with(entry.Options), writeOptions{}.with(b.entries[i].Options), "options of batch entry %d", i)
+			}
+		}
+	}
+	return expectation.errorObj
+}
+
+func (b *MockBatch[T]) ExecCtx(_ context.Context) error {
+	return b.Exec()
+}
+
+func NewMockUnitOfWork(sess *MockSession) *MockUnitOfWork {
+	return &MockUnitOfWork{sess: sess, entries: make([]UnitOfWorkEntry, 0)}
+}
+
+func (m *MockSession) AddUnitOfWorkExpectation(entries []UnitOfWorkEntry, err error) {
+	newExpect := unitOfWorkExpect{
+		entries:  entries,
+		errorObj: err,
+	}
+	m.Expectations = append(m.Expectations, newExpect)
+}
+
+func (u *MockUnitOfWork) Insert(obj interface{}) error {
+	u.entries = append(u.entries, UnitOfWorkEntry{Op: InsertOp, Obj: obj})
+	return nil
+}
+
+func (u *MockUnitOfWork) Update(obj interface{}) error {
+	u.entries = append(u.entries, UnitOfWorkEntry{Op: UpdateOp, Obj: obj})
+	return nil
+}
+
+func (u *MockUnitOfWork) Delete(obj interface{}) error {
+	u.entries = append(u.entries, UnitOfWorkEntry{Op: DeleteOp, Obj: obj})
+	return nil
+}
+
+// Entries Return the writes recorded so far, in the order they were added.
+func (u *MockUnitOfWork) Entries() []UnitOfWorkEntry {
+	return u.entries
+}
+
+func (u *MockUnitOfWork) Commit() error {
+	expectation, ok := nextExpectation[unitOfWorkExpect](u.sess, "unit of work")
+	if !ok {
+		return errUnexpectedCall
+	}
+	assert.Equal(u.sess.Test, expectation.entries, u.entries)
+	return expectation.errorObj
+}
+
+func (u *MockUnitOfWork) CommitCtx(_ context.Context) error {
+	return u.Commit()
+}
+
+func (m *MockTable[T]) InsertIfNotExists(obj T) (bool, T, error) {
+	return m.matchLWT(InsertOp, obj, nil)
+}
+
+func (m *MockTable[T]) UpdateIf(obj T, conditions ...Condition) (bool, T, error) {
+	return m.matchLWT(UpdateOp, obj, conditions)
+}
+
+func (m *MockTable[T]) DeleteIfExists(obj T) (bool, error) {
+	applied, _, err := m.matchLWT(DeleteOp, obj, nil)
+	return applied, err
+}
+
+func (m *MockTable[T]) InsertIfNotExistsCtx(_ context.Context, obj T) (bool, T, error) {
+	return m.InsertIfNotExists(obj)
+}
+
+func (m *MockTable[T]) UpdateIfCtx(_ context.Context, obj T, conditions ...Condition) (bool, T, error) {
+	return m.UpdateIf(obj, conditions...)
+}
+
+func (m *MockTable[T]) DeleteIfExistsCtx(_ context.Context, obj T) (bool, error) {
+	return m.DeleteIfExists(obj)
+}
+
+// AddLWTExpectation Script the outcome of a lightweight transaction, current is returned when not applied.
+// op is InsertOp for InsertIfNotExists, UpdateOp for UpdateIf and DeleteOp for DeleteIfExists.
+func (m *MockTable[T]) AddLWTExpectation(op Operation, input T, conditions []Condition, applied bool, current T, err error) {
+	newExpect := lwtExpect[T]{
+		op:          op,
+		expectInput: input,
+		conditions:  conditions,
+		applied:     applied,
+		current:     current,
+		errorObj:    err,
+	}
+	m.sess.Expectations = append(m.sess.Expectations, newExpect)
+}
+
+func (m *MockTable[T]) matchLWT(op Operation, obj T, conditions []Condition) (bool, T, error) {
+	var current T
+	expectation, ok := nextExpectation[lwtExpect[T]](m.sess, "query")
+	if !ok {
+		return false, current, errUnexpectedCall
+	}
+	assert.Equal(m.t, expectation.op, op)
+	assert.Equal(m.t, expectation.expectInput, obj)
+	assert.Equal(m.t, expectation.conditions, conditions)
+	return expectation.applied, expectation.current, expectation.errorObj
+}
+
+func (m *MockTable[T]) SelectPage(obj T, pageSize int, cursor []byte) ([]T, []byte, error) {
+	expectation, ok := nextExpectation[pageExpect[T]](m.sess, "query")
+	if !ok {
+		return nil, nil, errUnexpectedCall
+	}
+	assert.Equal(m.t, expectation.expectInput, obj)
+	assert.Equal(m.t, expectation.pageSize, pageSize)
+	assert.Equal(m.t, expectation.cursor, cursor)
+	return expectation.returnObj, expectation.nextCursor, expectation.errorObj
+}
+
+func (m *MockTable[T]) SelectPageCtx(_ context.Context, obj T, pageSize int, cursor []byte) ([]T, []byte, error) {
+	return m.SelectPage(obj, pageSize, cursor)
+}
+
+// AddSelectPageExpectation Script one page, cursor is the expected input cursor and next is the returned one.
+func (m *MockTable[T]) AddSelectPageExpectation(input T, pageSize int, cursor []byte, objs []T, next []byte) {
+	newExpect := pageExpect[T]{
+		expectInput: input,
+		pageSize:    pageSize,
+		cursor:      cursor,
+		returnObj:   objs,
+		nextCursor:  next,
+	}
+	m.sess.Expectations = append(m.sess.Expectations, newExpect)
+}
+
+// AddSelectPagesExpectation Script a multi-page response, cursors between pages are generated.
+func (m *MockTable[T]) AddSelectPagesExpectation(input T, pageSize int, pages ...[]T) {
+	var cursor []byte
+	for i, page := range pages {
+		var next []byte
+		if i < len(pages)-1 {
+			next = []byte("page-" + strconv.Itoa(i+1))
+		}
+		m.AddSelectPageExpectation(input, pageSize, cursor, page, next)
+		cursor = next
+	}
+}
+
+func (m *MockTable[T]) SelectEach(obj T, fn func(T) error) error {
+	expectation, ok := nextExpectation[streamExpect[T]](m.sess, "query")
+	if !ok {
+		return errUnexpectedCall
+	}
+	assert.Equal(m.t, expectation.expectInput, obj)
+	for _, row := range expectation.returnObj {
+		if err := fn(row); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+	return expectation.errorObj
+}
+
+func (m *MockTable[T]) SelectEachCtx(_ context.Context, obj T, fn func(T) error) error {
+	return m.SelectEach(obj, fn)
+}
+
+// AddSelectStreamExpectation Feed rows to a SelectEach callback, err is returned after all rows were streamed.
+func (m *MockTable[T]) AddSelectStreamExpectation(input T, objs []T, err error) {
+	newExpect := streamExpect[T]{
+		expectInput: input,
+		returnObj:   objs,
+		errorObj:    err,
+	}
+	m.sess.Expectations = append(m.sess.Expectations, newExpect)
+}
+
+func (m *MockTable[T]) Find(spec QuerySpec) ([]T, error) {
+	expectation, ok := nextExpectation[findExpect[T]](m.sess, "query")
+	if !ok {
+		return nil, errUnexpectedCall
+	}
+	assert.Equal(m.t, expectation.spec, spec)
+	return expectation.returnObj, expectation.errorObj
+}
+
+func (m *MockTable[T]) FindCtx(_ context.Context, spec QuerySpec) ([]T, error) {
+	return m.Find(spec)
+}
+
+// AddFindExpectation Expect a query structurally equal to spec, filters, ordering, limits and projection are all asserted.
+func (m *MockTable[T]) AddFindExpectation(spec QuerySpec, objs []T, err error) {
+	newExpect := findExpect[T]{
+		spec:      spec,
+		returnObj: objs,
+		errorObj:  err,
+	}
+	m.sess.Expectations = append(m.sess.Expectations, newExpect)
+}
+
+func (m *MockTable[T]) UpdateFields(obj T, columns ...string) error {
+	_, err := m.matchFields(obj, FieldMask{Columns: columns})
+	return err
+}
+
+func (m *MockTable[T]) SelectFields(obj T, mask FieldMask) ([]T, error) {
+	return m.matchFields(obj, mask)
+}
+
+func (m *MockTable[T]) DeleteFields(obj T, keys ...string) error {
+	_, err := m.matchFields(obj, FieldMask{Filter: keys})
+	return err
+}
+
+func (m *MockTable[T]) UpdateFieldsCtx(_ context.Context, obj T, columns ...string) error {
+	return m.UpdateFields(obj, columns...)
+}
+
+func (m *MockTable[T]) SelectFieldsCtx(_ context.Context, obj T, mask FieldMask) ([]T, error) {
+	return m.SelectFields(obj, mask)
+}
+
+func (m *MockTable[T]) DeleteFieldsCtx(_ context.Context, obj T, keys ...string) error {
+	return m.DeleteFields(obj, keys...)
+}
+
+func (m *MockTable[T]) AddUpdateFieldsExpectation(input T, columns []string, err error) {
+	m.addFieldsExpectation(input, FieldMask{Columns: columns}, nil, err)
+}
+
+func (m *MockTable[T]) AddSelectFieldsExpectation(input T, mask FieldMask, objs []T) {
+	m.addFieldsExpectation(input, mask, objs, nil)
+}
+
+func (m *MockTable[T]) AddDeleteFieldsExpectation(input T, keys []string, err error) {
+	m.addFieldsExpectation(input, FieldMask{Filter: keys}, nil, err)
+}
+
+func (m *MockTable[T]) addFieldsExpectation(input T, mask FieldMask, objs []T, err error) {
+	newExpect := fieldsExpect[T]{
+		expectInput: input,
+		mask:        mask,
+		returnObj:   objs,
+		errorObj:    err,
+	}
+	m.sess.Expectations = append(m.sess.Expectations, newExpect)
+}
+
+func (m *MockTable[T]) matchFields(obj T, mask FieldMask) ([]T, error) {
+	expectation, ok := nextExpectation[fieldsExpect[T]](m.sess, "query")
+	if !ok {
+		return nil, errUnexpectedCall
+	}
+	assert.Equal(m.t, expectation.expectInput, obj)
+	assert.Equal(m.t, expectation.mask, mask)
+	return expectation.returnObj, expectation.errorObj
+}
@@ -0,0 +1,95 @@
+package nosqlorm
+
+import (
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_MockUnexpectedCalls(t *testing.T) {
+	person := testPerson{Name: "tony", Age: 30}
+
+	// Running out of expectations fails the test instead of panicking
+	mockT := &testing.T{}
+	table := NewMockTable[testPerson](NewMockSession(mockT))
+	_, err := table.Select(person)
+	assert.ErrorIs(t, err, errUnexpectedCall)
+	assert.ErrorIs(t, table.NewBatch(gocql.LoggedBatch).Exec(), errUnexpectedCall)
+	assert.ErrorIs(t, NewMockUnitOfWork(table.sess).Commit(), errUnexpectedCall)
+	assert.True(t, mockT.Failed())
+
+	// So does an expectation of another kind
+	mockT = &testing.T{}
+	table = NewMockTable[testPerson](NewMockSession(mockT))
+	table.AddSelectExpectation(person, []testPerson{person})
+	_, _, err = table.SelectPage(person, 10, nil)
+	assert.ErrorIs(t, err, errUnexpectedCall)
+	assert.True(t, mockT.Failed())
+}
+
+func Test_MockLWTOperation(t *testing.T) {
+	person := testPerson{Name: "tony", Age: 30}
+	table := NewMockTable[testPerson](NewMockSession(t))
+	table.AddLWTExpectation(InsertOp, person, nil, true, testPerson{}, nil)
+	table.AddLWTExpectation(DeleteOp, person, nil, false, testPerson{}, nil)
+	applied, _, err := table.InsertIfNotExists(person)
+	assert.NoError(t, err)
+	assert.True(t, applied)
+	applied, err = table.DeleteIfExists(person)
+	assert.NoError(t, err)
+	assert.False(t, applied)
+
+	// The kind of the lightweight transaction is asserted
+	mockT := &testing.T{}
+	table = NewMockTable[testPerson](NewMockSession(mockT))
+	table.AddLWTExpectation(InsertOp, person, nil, true, testPerson{}, nil)
+	_, _ = table.DeleteIfExists(person)
+	assert.True(t, mockT.Failed())
+}
+
+func Test_MockSelectStream(t *testing.T) {
+	person := testPerson{Name: "tony", Age: 30}
+	table := NewMockTable[testPerson](NewMockSession(t))
+	table.AddSelectStreamExpectation(person, []testPerson{person, person}, nil)
+	count := 0
+	assert.NoError(t, table.SelectEach(person, func(testPerson) error {
+		count++
+		return nil
+	}))
+	assert.Equal(t, 2, count)
+
+	// Select and SelectEach expectations are not interchangeable
+	mockT := &testing.T{}
+	table = NewMockTable[testPerson](NewMockSession(mockT))
+	table.AddSelectExpectation(person, []testPerson{person})
+	table.AddSelectStreamExpectation(person, []testPerson{person}, nil)
+	assert.ErrorIs(t, table.SelectEach(person, func(testPerson) error { return nil }), errUnexpectedCall)
+	_, err := table.Select(person)
+	assert.ErrorIs(t, err, errUnexpectedCall)
+	assert.True(t, mockT.Failed())
+}
+
+func Test_MockBatchWriteOptions(t *testing.T) {
+	person := testPerson{Name: "tony", Age: 30}
+	table := NewMockTable[testPerson](NewMockSession(t))
+	table.AddBatchExpectation(gocql.LoggedBatch, []BatchEntry[testPerson]{
+		{Op: InsertOp, Obj: person, Options: []WriteOption{WithTTL(time.Minute)}},
+		// Options are not checked if the expected entry has none
+		{Op: UpdateOp, Obj: person},
+	}, nil)
+	batch := table.NewBatch(gocql.LoggedBatch)
+	assert.NoError(t, batch.Insert(person, WithTTL(time.Minute)))
+	assert.NoError(t, batch.Update(person, WithTTL(time.Hour)))
+	assert.NoError(t, batch.Exec())
+
+	mockT := &testing.T{}
+	table = NewMockTable[testPerson](NewMockSession(mockT))
+	table.AddBatchExpectation(gocql.LoggedBatch, []BatchEntry[testPerson]{
+		{Op: InsertOp, Obj: person, Options: []WriteOption{WithTTL(time.Minute)}},
+	}, nil)
+	batch = table.NewBatch(gocql.LoggedBatch)
+	assert.NoError(t, batch.Insert(person, WithTTL(time.Hour)))
+	assert.NoError(t, batch.Exec())
+	assert.True(t, mockT.Failed())
+}
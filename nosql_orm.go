@@ -1,8 +1,104 @@
 package nosqlorm
 
+import (
+	"context"
+	"github.com/gocql/gocql"
+)
+
 type NoSqlOrm[T any] interface {
-	Insert(T) error
+	Insert(T, ...WriteOption) error
+	Select(T) ([]T, error)
+	Update(T, ...WriteOption) error
+	Delete(T) error
+
+	// Context-aware variants, the context is used to cancel the query or put a deadline on it.
+	InsertCtx(context.Context, T, ...WriteOption) error
+	SelectCtx(context.Context, T) ([]T, error)
+	UpdateCtx(context.Context, T, ...WriteOption) error
+	DeleteCtx(context.Context, T) error
+
+	// Explicit field masks, only the named columns are used even if their values are zero.
+	UpdateFields(T, ...string) error
+	SelectFields(T, FieldMask) ([]T, error)
+	DeleteFields(T, ...string) error
+	UpdateFieldsCtx(context.Context, T, ...string) error
+	SelectFieldsCtx(context.Context, T, FieldMask) ([]T, error)
+	DeleteFieldsCtx(context.Context, T, ...string) error
+
+	// Paginated select, a nil cursor fetches the first page and a nil next cursor means the last page.
+	SelectPage(T, int, []byte) ([]T, []byte, error)
+	SelectPageCtx(context.Context, T, int, []byte) ([]T, []byte, error)
+
+	// Streaming select, rows are passed to the callback one at a time.
+	SelectEach(T, func(T) error) error
+	SelectEachCtx(context.Context, T, func(T) error) error
+
+	// Find runs a query described by a backend-neutral QuerySpec.
+	Find(QuerySpec) ([]T, error)
+	FindCtx(context.Context, QuerySpec) ([]T, error)
+
+	// Lightweight transactions, the current row is returned when the write was not applied.
+	InsertIfNotExists(T) (bool, T, error)
+	UpdateIf(T, ...Condition) (bool, T, error)
+	DeleteIfExists(T) (bool, error)
+	InsertIfNotExistsCtx(context.Context, T) (bool, T, error)
+	UpdateIfCtx(context.Context, T, ...Condition) (bool, T, error)
+	DeleteIfExistsCtx(context.Context, T) (bool, error)
+
+	// NewBatch starts a LOGGED, UNLOGGED or COUNTER batch on the table.
+	NewBatch(gocql.BatchType) Batch[T]
+}
+
+// NoSqlView is the read-only access of a materialized view, MockTable implements it as well.
+type NoSqlView[T any] interface {
 	Select(T) ([]T, error)
-	Update(T) error
+	SelectCtx(context.Context, T) ([]T, error)
+	SelectFields(T, FieldMask) ([]T, error)
+	SelectFieldsCtx(context.Context, T, FieldMask) ([]T, error)
+	SelectPage(T, int, []byte) ([]T, []byte, error)
+	SelectPageCtx(context.Context, T, int, []byte) ([]T, []byte, error)
+	SelectEach(T, func(T) error) error
+	SelectEachCtx(context.Context, T, func(T) error) error
+	Find(QuerySpec) ([]T, error)
+	FindCtx(context.Context, QuerySpec) ([]T, error)
+}
+
+// Batch collects write statements of one table and runs them in one round trip.
+type Batch[T any] interface {
+	Insert(T, ...WriteOption) error
+	Update(T, ...WriteOption) error
 	Delete(T) error
+	Exec() error
+	ExecCtx(context.Context) error
+}
+
+// Operation is the kind of write statement recorded in a batch.
+type Operation int
+
+const (
+	InsertOp Operation = iota
+	UpdateOp
+	DeleteOp
+)
+
+func (op Operation) String() string {
+	switch op {
+	case InsertOp:
+		return "INSERT"
+	case UpdateOp:
+		return "UPDATE"
+	case DeleteOp:
+		return "DELETE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// UnitOfWork groups writes of different model types and commits them together.
+type UnitOfWork interface {
+	Insert(interface{}) error
+	Update(interface{}) error
+	Delete(interface{}) error
+	Commit() error
+	CommitCtx(context.Context) error
 }
@@ -0,0 +1,59 @@
+package nosqlorm
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+)
+
+// SelectPage Fetch one page of rows, pass the returned cursor to fetch the next page.
+// A nil cursor fetches the first page, and a nil next cursor means there are no more pages.
+func (ctx *cqlOrm[T]) SelectPage(obj T, pageSize int, cursor []byte) ([]T, []byte, error) {
+	return ctx.SelectPageCtx(context.Background(), obj, pageSize, cursor)
+}
+
+// SelectPageCtx is the same as SelectPage, the query is bound to the given context.
+func (ctx *cqlOrm[T]) SelectPageCtx(c context.Context, obj T, pageSize int, cursor []byte) ([]T, []byte, error) {
+	if pageSize <= 0 {
+		return []T{}, nil, errors.New("page size must be positive")
+	}
+	sql, selectFields, whereValues, err := buildSelectStatement(reflect.ValueOf(obj), ctx.table(), nil)
+	if err != nil {
+		return []T{}, nil, err
+	}
+	return ctx.selectPage(c, sql, selectFields, whereValues, pageSize, cursor)
+}
+
+func (ctx *cqlOrm[T]) selectPage(c context.Context, sql string, selectFields []string, values []interface{}, pageSize int, cursor []byte) ([]T, []byte, error) {
+	var t T
+	schema, _, err := loadTableSchema(reflect.TypeOf(t), "")
+	if err != nil {
+		return []T{}, nil, err
+	}
+
+	// Setting page state disables auto paging, so only the requested page is fetched
+	iter := ctx.sess.Query(sql, values...).WithContext(c).PageSize(pageSize).PageState(cursor).Iter()
+	nextCursor := iter.PageState()
+	rows := scanRows[T](iter, selectFields, schema)
+	if err := iter.Close(); err != nil {
+		return []T{}, nil, err
+	}
+	if len(nextCursor) == 0 {
+		nextCursor = nil
+	}
+	return rows, nextCursor, nil
+}
+
+// EncodeCursor Encode a page cursor as an URL safe token, e.g. for REST APIs.
+func EncodeCursor(cursor []byte) string {
+	return base64.RawURLEncoding.EncodeToString(cursor)
+}
+
+// DecodeCursor Decode a token created by EncodeCursor, an empty token means the first page.
+func DecodeCursor(token string) ([]byte, error) {
+	if token == "" {
+		return nil, nil
+	}
+	return base64.RawURLEncoding.DecodeString(token)
+}
@@ -0,0 +1,37 @@
+package nosqlorm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+func Test_Cursor(t *testing.T) {
+	cursor := []byte{0x00, 0xff, 0xfe, '/', '+'}
+	token := EncodeCursor(cursor)
+	assert.NotContains(t, token, "/")
+	assert.NotContains(t, token, "+")
+	assert.NotContains(t, token, "=")
+
+	decoded, err := DecodeCursor(token)
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	decoded, err = DecodeCursor("")
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	_, err = DecodeCursor("not a token!")
+	assert.Error(t, err)
+}
+
+func Test_BuildSelectStatement(t *testing.T) {
+	_, err := NewCqlOrm[testPerson](nil)
+	assert.NoError(t, err)
+
+	sql, fields, values, err := buildSelectStatement(reflect.ValueOf(testPerson{Name: "tony", Age: 30}), "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT name, age, address FROM testperson WHERE name=? AND age=?;", sql)
+	assert.Equal(t, []string{"name", "age", "address"}, fields)
+	assert.Equal(t, []interface{}{"tony", int8(30)}, values)
+}
@@ -0,0 +1,281 @@
+package nosqlorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Ordering is one column of an ORDER BY clause.
+type Ordering struct {
+	Column string
+	Desc   bool
+}
+
+func Asc(column string) Ordering {
+	return Ordering{Column: column}
+}
+
+func Desc(column string) Ordering {
+	return Ordering{Column: column, Desc: true}
+}
+
+// QuerySpec is a backend-neutral description of a query, rendered to CQL by cqlOrm and matched structurally by MockTable.
+type QuerySpec struct {
+	Filters           []Condition
+	OrderBy           []Ordering
+	Limit             int
+	PerPartitionLimit int
+	// Columns to fetch, empty means all columns
+	Columns []string
+}
+
+type cqlQuery[T interface{}] struct {
+	orm  *cqlOrm[T]
+	spec QuerySpec
+}
+
+// Query Start a query with range predicates on clustering keys, ORDER BY and LIMIT.
+func (ctx *cqlOrm[T]) Query() *cqlQuery[T] {
+	return &cqlQuery[T]{orm: ctx}
+}
+
+// Where Add filters, all filters are combined with AND.
+func (q *cqlQuery[T]) Where(conditions ...Condition) *cqlQuery[T] {
+	q.spec.Filters = append(q.spec.Filters, conditions...)
+	return q
+}
+
+// OrderBy Order rows by clustering keys, use Asc or Desc to build the orderings.
+func (q *cqlQuery[T]) OrderBy(orderings ...Ordering) *cqlQuery[T] {
+	q.spec.OrderBy = append(q.spec.OrderBy, orderings...)
+	return q
+}
+
+func (q *cqlQuery[T]) Limit(limit int) *cqlQuery[T] {
+	q.spec.Limit = limit
+	return q
+}
+
+func (q *cqlQuery[T]) PerPartitionLimit(limit int) *cqlQuery[T] {
+	q.spec.PerPartitionLimit = limit
+	return q
+}
+
+// Columns Only fetch the given columns, other fields of the returned rows are left zero.
+func (q *cqlQuery[T]) Columns(columns ...string) *cqlQuery[T] {
+	q.spec.Columns = append(q.spec.Columns, columns...)
+	return q
+}
+
+// Spec Return the query as a QuerySpec, which could be passed to Find of any NoSqlOrm implementation.
+func (q *cqlQuery[T]) Spec() QuerySpec {
+	return q.spec
+}
+
+// Find Run a query described by a QuerySpec.
+func (ctx *cqlOrm[T]) Find(spec QuerySpec) ([]T, error) {
+	return ctx.FindCtx(context.Background(), spec)
+}
+
+// FindCtx is the same as Find, the query is bound to the given context.
+func (ctx *cqlOrm[T]) FindCtx(c context.Context, spec QuerySpec) ([]T, error) {
+	return (&cqlQuery[T]{orm: ctx, spec: spec}).SelectCtx(c)
+}
+
+func (q *cqlQuery[T]) Select() ([]T, error) {
+	return q.SelectCtx(context.Background())
+}
+
+// SelectCtx is the same as Select, the query is bound to the given context.
+func (q *cqlQuery[T]) SelectCtx(c context.Context) ([]T, error) {
+	rows := make([]T, 0)
+	err := q.SelectEachCtx(c, func(row T) error {
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return []T{}, err
+	}
+	return rows, nil
+}
+
+// SelectPage Fetch one page of the query result, see cqlOrm.SelectPage for the cursor semantic.
+func (q *cqlQuery[T]) SelectPage(pageSize int, cursor []byte) ([]T, []byte, error) {
+	return q.SelectPageCtx(context.Background(), pageSize, cursor)
+}
+
+// SelectPageCtx is the same as SelectPage, the query is bound to the given context.
+func (q *cqlQuery[T]) SelectPageCtx(c context.Context, pageSize int, cursor []byte) ([]T, []byte, error) {
+	if pageSize <= 0 {
+		return []T{}, nil, errors.New("page size must be positive")
+	}
+	sql, selectFields, values, err := q.build()
+	if err != nil {
+		return []T{}, nil, err
+	}
+	return q.orm.selectPage(c, sql, selectFields, values, pageSize, cursor)
+}
+
+// SelectEach Stream the query result to the callback, see cqlOrm.SelectEach.
+func (q *cqlQuery[T]) SelectEach(fn func(T) error) error {
+	return q.SelectEachCtx(context.Background(), fn)
+}
+
+// SelectEachCtx is the same as SelectEach, the query is bound to the given context.
+func (q *cqlQuery[T]) SelectEachCtx(c context.Context, fn func(T) error) error {
+	sql, selectFields, values, err := q.build()
+	if err != nil {
+		return err
+	}
+	return q.orm.selectEach(c, sql, selectFields, values, fn)
+}
+
+func (q *cqlQuery[T]) build() (string, []string, []interface{}, error) {
+	var t T
+	schema, tableName, err := loadTableSchema(reflect.TypeOf(t), q.orm.table())
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return buildQueryStatement(schema, tableName, q.spec)
+}
+
+// Build SELECT statement of a query, columns and operators are validated against the table schema.
+func buildQueryStatement(schema tableSchema, tableName string, spec QuerySpec) (string, []string, []interface{}, error) {
+	for _, cond := range spec.Filters {
+		field, ok := schema.fieldMap[cond.Column]
+		if !ok || cond.Column == "-" {
+			return "", nil, nil, errors.New(fmt.Sprintf("Invalid filter: column %s not found", cond.Column))
+		}
+		op := strings.ToUpper(cond.Op)
+		isKey := field.isPartitionKey || field.isClusteringKey
+		isRange := op == ">" || op == ">=" || op == "<" || op == "<="
+		switch {
+		case !isKey && field.index == "":
+			return "", nil, nil, errors.New(fmt.Sprintf("Invalid filter: %s is not a key or indexed column", cond.Column))
+		case op == "=":
+		case op == "IN" && isKey:
+		case isRange && (field.isClusteringKey || field.index == "sai" || field.index == "sasi"):
+		default:
+			return "", nil, nil, errors.New(fmt.Sprintf("Invalid filter: operator %s is not allowed on %s", cond.Op, cond.Column))
+		}
+	}
+	if err := checkQueryRestrictions(schema, tableName, spec); err != nil {
+		return "", nil, nil, err
+	}
+	whereClause, values, err := renderConditions(schema, spec.Filters)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	selectFields := make([]string, 0)
+	for _, fieldName := range schema.fields {
+		if fieldName != "-" {
+			selectFields = append(selectFields, fieldName)
+		}
+	}
+	if len(spec.Columns) > 0 {
+		if err := checkColumns(schema, spec.Columns); err != nil {
+			return "", nil, nil, err
+		}
+		selectFields = spec.Columns
+	}
+
+	sql := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selectFields, ", "), tableName)
+	if len(whereClause) > 0 {
+		sql += " WHERE " + strings.Join(whereClause, " AND ")
+	}
+	if len(spec.OrderBy) > 0 {
+		orderings := make([]string, 0, len(spec.OrderBy))
+		for _, ordering := range spec.OrderBy {
+			if !schema.fieldMap[ordering.Column].isClusteringKey || ordering.Column == "-" {
+				return "", nil, nil, errors.New(fmt.Sprintf("Invalid ordering: %s is not a clustering key", ordering.Column))
+			}
+			direction := "ASC"
+			if ordering.Desc {
+				direction = "DESC"
+			}
+			orderings = append(orderings, ordering.Column+" "+direction)
+		}
+		sql += " ORDER BY " + strings.Join(orderings, ", ")
+	}
+	if spec.PerPartitionLimit > 0 {
+		sql += " PER PARTITION LIMIT ?"
+		values = append(values, spec.PerPartitionLimit)
+	}
+	if spec.Limit > 0 {
+		sql += " LIMIT ?"
+		values = append(values, spec.Limit)
+	}
+	return sql + ";", selectFields, values, nil
+}
+
+// A query without filters is a full table scan, otherwise the partition key must be restricted and
+// only the last restricted clustering key could have a range predicate.
+// A query on indexed columns only needs no partition key, but could not be ordered.
+func checkQueryRestrictions(schema tableSchema, tableName string, spec QuerySpec) error {
+	if len(spec.Filters) == 0 && len(spec.OrderBy) == 0 {
+		return nil
+	}
+	restricted := make(map[string]bool)
+	rangeColumns := make(map[string]bool)
+	indexed := make([]string, 0)
+	nonKeyIndexed := false
+	for _, cond := range spec.Filters {
+		restricted[cond.Column] = true
+		if cond.Op != "=" && strings.ToUpper(cond.Op) != "IN" {
+			rangeColumns[cond.Column] = true
+		}
+		if field := schema.fieldMap[cond.Column]; field.index != "" {
+			indexed = append(indexed, cond.Column)
+			nonKeyIndexed = nonKeyIndexed || !field.isClusteringKey
+		}
+	}
+	indexQuery := len(indexed) > 0 && len(indexed) == len(spec.Filters)
+	if indexQuery || nonKeyIndexed {
+		if len(spec.OrderBy) > 0 {
+			return errors.New(fmt.Sprintf("Invalid ordering: queries on indexed column %s could not be ordered", indexed[0]))
+		}
+		legacy := 0
+		for _, column := range indexed {
+			if schema.fieldMap[column].index != "legacy" {
+				continue
+			}
+			legacy++
+			if indexQuery && rangeColumns[column] {
+				return errors.New(fmt.Sprintf("Invalid filter: range predicate on %s needs a sai or sasi index", column))
+			}
+		}
+		if legacy > 1 {
+			return errors.New(fmt.Sprintf("Invalid filter: only one legacy indexed column could be restricted, got %s", strings.Join(indexed, ", ")))
+		}
+	}
+	if indexQuery {
+		return nil
+	}
+	if err := checkPrimaryKey(schema, tableName, "SELECT", restricted, false); err != nil {
+		return err
+	}
+	rangeColumn := ""
+	for _, key := range schema.clusteringKeys {
+		if rangeColumn != "" && restricted[key] && key != rangeColumn {
+			return errors.New(fmt.Sprintf("Invalid filter: range predicate on %s must be on the last restricted clustering key", rangeColumn))
+		}
+		if rangeColumns[key] {
+			rangeColumn = key
+		}
+	}
+	return nil
+}
+
+// Validate projection columns against the table schema
+func checkColumns(schema tableSchema, columns []string) error {
+	for _, column := range columns {
+		if _, ok := schema.fieldMap[column]; !ok || column == "-" {
+			return errors.New(fmt.Sprintf("Invalid projection: column %s not found", column))
+		}
+	}
+	return nil
+}
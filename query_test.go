@@ -0,0 +1,60 @@
+package nosqlorm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type testEvent struct {
+	User    string    `json:"user" cql:"pk"`
+	Ts      time.Time `json:"ts" cql:"ck"`
+	Kind    string    `json:"kind"`
+	Payload string    `json:"payload"`
+}
+
+func Test_BuildQueryStatement(t *testing.T) {
+	orm, err := NewCqlOrm[testEvent](nil)
+	assert.NoError(t, err)
+	t1 := time.Unix(1700000000, 0)
+	t2 := t1.Add(time.Hour)
+
+	query := orm.Query().
+		Where(Eq("user", "tony"), Gt("ts", t1), Lte("ts", t2)).
+		OrderBy(Desc("ts")).
+		Limit(50)
+	assert.Equal(t, QuerySpec{
+		Filters: []Condition{Eq("user", "tony"), Gt("ts", t1), Lte("ts", t2)},
+		OrderBy: []Ordering{Desc("ts")},
+		Limit:   50,
+	}, query.Spec())
+	sql, fields, values, err := query.build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT user, ts, kind, payload FROM testevent WHERE user=? AND ts>? AND ts<=? ORDER BY ts DESC LIMIT ?;", sql)
+	assert.Equal(t, []string{"user", "ts", "kind", "payload"}, fields)
+	assert.Equal(t, []interface{}{"tony", t1, t2, 50}, values)
+
+	sql, _, values, err = orm.Query().Where(In("user", "tony", "tom")).PerPartitionLimit(1).build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT user, ts, kind, payload FROM testevent WHERE user IN ? PER PARTITION LIMIT ?;", sql)
+	assert.Equal(t, []interface{}{[]interface{}{"tony", "tom"}, 1}, values)
+
+	sql, fields, _, err = orm.Query().Where(Eq("user", "tony")).Columns("ts", "kind").build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT ts, kind FROM testevent WHERE user=?;", sql)
+	assert.Equal(t, []string{"ts", "kind"}, fields)
+
+	// Invalid columns and predicates
+	_, _, _, err = orm.Query().Columns("unknown").build()
+	assert.Error(t, err)
+	_, _, _, err = orm.Query().Where(Eq("unknown", 1)).build()
+	assert.Error(t, err)
+	_, _, _, err = orm.Query().Where(Gt("user", "tony")).build()
+	assert.Error(t, err)
+	_, _, _, err = orm.Query().Where(Eq("kind", "click")).build()
+	assert.Error(t, err)
+	_, _, _, err = orm.Query().Where(Ne("ts", t1)).build()
+	assert.Error(t, err)
+	_, _, _, err = orm.Query().Where(Eq("user", "tony")).OrderBy(Asc("kind")).build()
+	assert.Error(t, err)
+}
@@ -0,0 +1,329 @@
+package nosqlorm
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gocql/gocql"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrColumnTypeChanged  = errors.New("column type changed")
+	ErrPrimaryKeyChanged  = errors.New("primary key changed")
+	ErrColumnKindChanged  = errors.New("static column changed")
+	ErrMissingTableColumn = errors.New("column missing in table")
+	ErrClusteringOrder    = errors.New("clustering order changed")
+	ErrTableOptionChanged = errors.New("table option changed")
+	ErrMissingIndex       = errors.New("index missing in table")
+	ErrIndexChanged       = errors.New("index changed")
+	ErrTableNotFound      = errors.New("table not found")
+)
+
+// TableNotFoundError is returned by schema verification when the table does not exist, it wraps ErrTableNotFound.
+type TableNotFoundError struct {
+	Keyspace string
+	Table    string
+}
+
+func (e *TableNotFoundError) Error() string {
+	return fmt.Sprintf("%s: %s.%s", ErrTableNotFound.Error(), e.Keyspace, e.Table)
+}
+
+func (e *TableNotFoundError) Unwrap() error {
+	return ErrTableNotFound
+}
+
+// ColumnMismatch is a column whose definition in the model differs from the live table,
+// Column is the option name for table options and the index name for indexes.
+type ColumnMismatch struct {
+	Column string
+	// Definition in the model and in the table, empty if missing
+	Model string
+	Table string
+	Err   error
+}
+
+func (m ColumnMismatch) String() string {
+	return fmt.Sprintf("%s: %s (model: %s, table: %s)", m.Column, m.Err.Error(), orNone(m.Model), orNone(m.Table))
+}
+
+// SchemaError is returned when a table could not be brought in line with its model,
+// use errors.Is with ErrColumnTypeChanged, ErrPrimaryKeyChanged, ErrColumnKindChanged, ErrMissingTableColumn,
+// ErrClusteringOrder, ErrTableOptionChanged, ErrMissingIndex or ErrIndexChanged to tell the reason.
+type SchemaError struct {
+	Table      string
+	Mismatches []ColumnMismatch
+}
+
+func (e *SchemaError) Error() string {
+	mismatches := make([]string, 0, len(e.Mismatches))
+	for _, mismatch := range e.Mismatches {
+		mismatches = append(mismatches, mismatch.String())
+	}
+	return fmt.Sprintf("table %s does not match the model: %s", e.Table, strings.Join(mismatches, "; "))
+}
+
+func (e *SchemaError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Mismatches))
+	for _, mismatch := range e.Mismatches {
+		errs = append(errs, mismatch.Err)
+	}
+	return errs
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// Column definition read from system_schema.columns
+type liveColumn struct {
+	name     string
+	kind     string
+	dataType string
+	position int
+	// asc or desc for clustering columns, none for others
+	clusteringOrder string
+}
+
+// Read columns of a table from system_schema.columns, an empty result means the table does not exist
+func readTableColumns(sess *gocql.Session, keyspace string, tableName string) ([]liveColumn, error) {
+	if keyspace == "" {
+		return nil, errors.New(fmt.Sprintf("Read table %s failed: no keyspace", tableName))
+	}
+	iter := sess.Query("SELECT column_name, kind, type, position, clustering_order FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ?;",
+		keyspace, tableName).Iter()
+	columns := make([]liveColumn, 0)
+	var column liveColumn
+	for iter.Scan(&column.name, &column.kind, &column.dataType, &column.position, &column.clusteringOrder) {
+		columns = append(columns, column)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, errors.New(fmt.Sprintf("Read table %s failed: %s", tableName, err.Error()))
+	}
+	return columns, nil
+}
+
+// Kind of a model column as named by system_schema.columns
+func columnKind(column CqlColumn) string {
+	switch {
+	case column.PartitionKey:
+		return "partition_key"
+	case column.ClusteringKey:
+		return "clustering"
+	case column.Static:
+		return "static"
+	default:
+		return "regular"
+	}
+}
+
+func (column CqlColumn) definition() string {
+	if column.Name == "-" {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", column.Type, columnKind(column))
+}
+
+// Clustering order as named by system_schema.columns
+func (column CqlColumn) clusteringOrder() string {
+	if column.Descending {
+		return "desc"
+	}
+	return "asc"
+}
+
+func (column liveColumn) definition() string {
+	return fmt.Sprintf("%s %s", column.dataType, column.kind)
+}
+
+// Compare model columns with the live table. Missing regular and static columns are returned as mismatches
+// with ErrMissingTableColumn, they could be added. Columns only existing in the table are left alone.
+func diffTableColumns(columns []CqlColumn, live []liveColumn) []ColumnMismatch {
+	liveMap := make(map[string]liveColumn, len(live))
+	for _, column := range live {
+		liveMap[column.name] = column
+	}
+	modelMap := make(map[string]CqlColumn, len(columns))
+
+	mismatches := make([]ColumnMismatch, 0)
+	for _, column := range columns {
+		if column.Name == "-" {
+			continue
+		}
+		modelMap[column.Name] = column
+		kind := columnKind(column)
+		liveCol, ok := liveMap[column.Name]
+		switch {
+		case !ok && (column.PartitionKey || column.ClusteringKey):
+			mismatches = append(mismatches, ColumnMismatch{Column: column.Name, Model: column.definition(), Err: ErrPrimaryKeyChanged})
+		case !ok:
+			mismatches = append(mismatches, ColumnMismatch{Column: column.Name, Model: column.definition(), Err: ErrMissingTableColumn})
+		case (isKeyKind(kind) || isKeyKind(liveCol.kind)) && kind != liveCol.kind:
+			mismatches = append(mismatches, ColumnMismatch{Column: column.Name, Model: column.definition(), Table: liveCol.definition(), Err: ErrPrimaryKeyChanged})
+		case kind != liveCol.kind:
+			mismatches = append(mismatches, ColumnMismatch{Column: column.Name, Model: column.definition(), Table: liveCol.definition(), Err: ErrColumnKindChanged})
+		case column.Type != liveCol.dataType:
+			mismatches = append(mismatches, ColumnMismatch{Column: column.Name, Model: column.definition(), Table: liveCol.definition(), Err: ErrColumnTypeChanged})
+		case column.ClusteringKey && liveCol.clusteringOrder != "" && liveCol.clusteringOrder != column.clusteringOrder():
+			mismatches = append(mismatches, ColumnMismatch{Column: column.Name, Model: column.definition() + " " + column.clusteringOrder(),
+				Table: liveCol.definition() + " " + liveCol.clusteringOrder, Err: ErrClusteringOrder})
+		}
+	}
+
+	// Key columns of the table which are not in the model, or in a different order
+	for _, kind := range []string{"partition_key", "clustering"} {
+		modelKeys := keyColumns(columns, kind)
+		for _, column := range live {
+			if column.kind != kind {
+				continue
+			}
+			if _, ok := modelMap[column.name]; !ok {
+				mismatches = append(mismatches, ColumnMismatch{Column: column.name, Table: column.definition(), Err: ErrPrimaryKeyChanged})
+			} else if column.position < len(modelKeys) && modelKeys[column.position] != column.name {
+				mismatches = append(mismatches, ColumnMismatch{Column: column.name, Model: modelMap[column.name].definition(),
+					Table: fmt.Sprintf("%s at position %d", column.definition(), column.position), Err: ErrPrimaryKeyChanged})
+			}
+		}
+	}
+	return mismatches
+}
+
+func isKeyKind(kind string) bool {
+	return kind == "partition_key" || kind == "clustering"
+}
+
+func keyColumns(columns []CqlColumn, kind string) []string {
+	keys := make([]string, 0)
+	for _, column := range columns {
+		if column.Name != "-" && columnKind(column) == kind {
+			keys = append(keys, column.Name)
+		}
+	}
+	return keys
+}
+
+// WithSchemaVerification Compare the model with its table in system_schema when creating the ORM object,
+// NewCqlOrm returns a *SchemaError listing every difference, so that deploys fail fast on schema drift.
+func WithSchemaVerification() OrmOption {
+	return func(opts *ormOptions) {
+		opts.verifySchema = true
+	}
+}
+
+func verifyTableSchema(sess *gocql.Session, keyspace string, tableName string, schema tableSchema) error {
+	live, err := readTableColumns(sess, keyspace, tableName)
+	if err != nil {
+		return err
+	}
+	if err := checkTableExists(keyspace, tableName, live); err != nil {
+		return err
+	}
+	mismatches := diffTableColumns(schema.definition.columns, live)
+	liveOptions, err := readTableOptions(sess, keyspace, tableName, schema.definition.base != nil)
+	if err != nil {
+		return err
+	}
+	mismatches = append(mismatches, diffTableOptions(schema.definition.options, liveOptions)...)
+	liveIndexes, err := readTableIndexes(sess, keyspace, tableName)
+	if err != nil {
+		return err
+	}
+	def := schema.definition
+	def.name = tableName
+	mismatches = append(mismatches, diffTableIndexes(def, liveIndexes)...)
+	if len(mismatches) > 0 {
+		return &SchemaError{Table: tableName, Mismatches: mismatches}
+	}
+	return nil
+}
+
+// A table without columns in system_schema.columns does not exist, it is not reported column by column
+func checkTableExists(keyspace string, tableName string, live []liveColumn) error {
+	if len(live) == 0 {
+		return &TableNotFoundError{Keyspace: keyspace, Table: tableName}
+	}
+	return nil
+}
+
+// Table options read from system_schema.tables
+type liveTableOptions struct {
+	comment        string
+	defaultTTL     int
+	gcGraceSeconds int
+	compaction     map[string]string
+}
+
+// Read options of a table from system_schema.tables, or of a materialized view from system_schema.views
+func readTableOptions(sess *gocql.Session, keyspace string, tableName string, isView bool) (liveTableOptions, error) {
+	sql := "SELECT comment, default_time_to_live, gc_grace_seconds, compaction FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?;"
+	if isView {
+		sql = "SELECT comment, default_time_to_live, gc_grace_seconds, compaction FROM system_schema.views WHERE keyspace_name = ? AND view_name = ?;"
+	}
+	options := liveTableOptions{}
+	err := sess.Query(sql, keyspace, tableName).Scan(&options.comment, &options.defaultTTL, &options.gcGraceSeconds, &options.compaction)
+	if err != nil {
+		return liveTableOptions{}, errors.New(fmt.Sprintf("Read table %s failed: %s", tableName, err.Error()))
+	}
+	return options, nil
+}
+
+// Compare the table options declared by a model, options left out by the model are not checked
+func diffTableOptions(options TableOptions, live liveTableOptions) []ColumnMismatch {
+	mismatches := make([]ColumnMismatch, 0)
+	mismatch := func(option string, model interface{}, table interface{}) {
+		mismatches = append(mismatches, ColumnMismatch{Column: option, Model: fmt.Sprint(model), Table: fmt.Sprint(table), Err: ErrTableOptionChanged})
+	}
+	if options.Comment != "" && options.Comment != live.comment {
+		mismatch("comment", options.Comment, live.comment)
+	}
+	if options.DefaultTTL > 0 && int(options.DefaultTTL/time.Second) != live.defaultTTL {
+		mismatch("default_time_to_live", int(options.DefaultTTL/time.Second), live.defaultTTL)
+	}
+	if options.GcGraceSeconds != nil && *options.GcGraceSeconds != live.gcGraceSeconds {
+		mismatch("gc_grace_seconds", *options.GcGraceSeconds, live.gcGraceSeconds)
+	}
+	keys := make([]string, 0, len(options.Compaction))
+	for key := range options.Compaction {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, liveValue := options.Compaction[key], live.compaction[key]
+		// Classes are stored with their package name
+		if value != liveValue && !(key == "class" && strings.HasSuffix(liveValue, "."+value)) {
+			mismatch("compaction."+key, value, liveValue)
+		}
+	}
+	return mismatches
+}
+
+// ALTER TABLE statements adding the missing columns, other mismatches could not be applied and are returned as an error
+func alterTableStatements(tableName string, columns []CqlColumn, live []liveColumn) ([]string, error) {
+	statements := make([]string, 0)
+	incompatible := make([]ColumnMismatch, 0)
+	for _, mismatch := range diffTableColumns(columns, live) {
+		if mismatch.Err != ErrMissingTableColumn {
+			incompatible = append(incompatible, mismatch)
+			continue
+		}
+		for _, column := range columns {
+			if column.Name != mismatch.Column {
+				continue
+			}
+			isStatic := ""
+			if column.Static {
+				isStatic = " static"
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD %s %s%s;", tableName, column.Name, column.Type, isStatic))
+		}
+	}
+	if len(incompatible) > 0 {
+		return nil, &SchemaError{Table: tableName, Mismatches: incompatible}
+	}
+	return statements, nil
+}
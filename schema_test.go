@@ -0,0 +1,169 @@
+package nosqlorm
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testAccount struct {
+	Tenant  string  `json:"tenant" cql:"pk"`
+	Id      string  `json:"id" cql:"ck"`
+	Plan    string  `json:"plan" cql:"static"`
+	Email   string  `json:"email"`
+	Balance float64 `json:"balance"`
+	Scratch int     `json:"-"`
+}
+
+func testAccountTable() []liveColumn {
+	return []liveColumn{
+		{name: "tenant", kind: "partition_key", dataType: "text", position: 0},
+		{name: "id", kind: "clustering", dataType: "text", position: 0},
+		{name: "plan", kind: "static", dataType: "text", position: -1},
+		{name: "email", kind: "regular", dataType: "text", position: -1},
+		{name: "legacy", kind: "regular", dataType: "int", position: -1},
+	}
+}
+
+func Test_AlterTableStatements(t *testing.T) {
+	columns, err := modelColumns(reflect.TypeOf(testAccount{}))
+	assert.NoError(t, err)
+
+	// New columns are added, columns only existing in the table are left alone
+	statements, err := alterTableStatements("testaccount", columns, testAccountTable())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ALTER TABLE testaccount ADD balance double;"}, statements)
+
+	live := testAccountTable()[0:2]
+	statements, err = alterTableStatements("testaccount", columns, live)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"ALTER TABLE testaccount ADD plan text static;",
+		"ALTER TABLE testaccount ADD email text;",
+		"ALTER TABLE testaccount ADD balance double;",
+	}, statements)
+
+	// Up to date
+	live = append(testAccountTable(), liveColumn{name: "balance", kind: "regular", dataType: "double", position: -1})
+	statements, err = alterTableStatements("testaccount", columns, live)
+	assert.NoError(t, err)
+	assert.Empty(t, statements)
+}
+
+func Test_AlterTableIncompatible(t *testing.T) {
+	columns, err := modelColumns(reflect.TypeOf(testAccount{}))
+	assert.NoError(t, err)
+
+	live := testAccountTable()
+	live[3].dataType = "int"
+	_, err = alterTableStatements("testaccount", columns, live)
+	var schemaErr *SchemaError
+	assert.True(t, errors.As(err, &schemaErr))
+	assert.True(t, errors.Is(err, ErrColumnTypeChanged))
+	assert.Equal(t, "testaccount", schemaErr.Table)
+	assert.Equal(t, "table testaccount does not match the model: email: column type changed (model: text regular, table: int regular)", err.Error())
+
+	live = testAccountTable()
+	live[2].kind = "regular"
+	_, err = alterTableStatements("testaccount", columns, live)
+	assert.True(t, errors.Is(err, ErrColumnKindChanged))
+
+	// Clustering key became a regular column
+	live = testAccountTable()
+	live[1].kind = "regular"
+	_, err = alterTableStatements("testaccount", columns, live)
+	assert.True(t, errors.Is(err, ErrPrimaryKeyChanged))
+
+	// Extra key column in the table
+	live = append(testAccountTable(), liveColumn{name: "region", kind: "partition_key", dataType: "text", position: 1})
+	_, err = alterTableStatements("testaccount", columns, live)
+	assert.True(t, errors.Is(err, ErrPrimaryKeyChanged))
+	assert.False(t, errors.Is(err, ErrColumnTypeChanged))
+}
+
+func Test_SchemaDrift(t *testing.T) {
+	_, err := NewCqlOrm[testAccount](nil)
+	assert.NoError(t, err)
+	schema, _, err := loadTableSchema(reflect.TypeOf(testAccount{}), "")
+	assert.NoError(t, err)
+	columns := schema.definition.columns
+	assert.Len(t, columns, 6)
+	assert.Equal(t, CqlColumn{Name: "plan", Type: "text", Static: true}, columns[2])
+
+	live := append(testAccountTable(), liveColumn{name: "balance", kind: "regular", dataType: "double", position: -1})
+	assert.Empty(t, diffTableColumns(columns, live))
+
+	// Missing column and missing table
+	mismatches := diffTableColumns(columns, testAccountTable())
+	assert.Equal(t, []ColumnMismatch{{Column: "balance", Model: "double regular", Err: ErrMissingTableColumn}}, mismatches)
+	assert.Len(t, diffTableColumns(columns, nil), 5)
+	err = checkTableExists("shop", "testaccount", nil)
+	assert.True(t, errors.Is(err, ErrTableNotFound))
+	var schemaErr *SchemaError
+	assert.False(t, errors.As(err, &schemaErr))
+	assert.Equal(t, "table not found: shop.testaccount", err.Error())
+	assert.NoError(t, checkTableExists("shop", "testaccount", testAccountTable()))
+
+	// Clustering order
+	live[1].clusteringOrder = "desc"
+	mismatches = diffTableColumns(columns, live)
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, ErrClusteringOrder, mismatches[0].Err)
+	assert.Equal(t, "id: clustering order changed (model: text clustering asc, table: text clustering desc)", mismatches[0].String())
+}
+
+type testTimeline struct {
+	UserId string    `json:"user_id" cql:"pk"`
+	Ts     time.Time `json:"ts" cql:"ck,desc"`
+	Seq    int       `json:"seq" cql:"ck"`
+	Body   string    `json:"body"`
+}
+
+func (testTimeline) TableOptions() TableOptions {
+	return TableOptions{
+		Compaction:     map[string]string{"class": "TimeWindowCompactionStrategy", "compaction_window_unit": "DAYS"},
+		GcGraceSeconds: GetPointer(3600),
+		DefaultTTL:     24 * time.Hour,
+		Comment:        "user's timeline",
+	}
+}
+
+func Test_TableOptionsDrift(t *testing.T) {
+	_, err := NewCqlOrm[testTimeline](nil)
+	assert.NoError(t, err)
+	schema, _, err := loadTableSchema(reflect.TypeOf(testTimeline{}), "")
+	assert.NoError(t, err)
+
+	live := []liveColumn{
+		{name: "user_id", kind: "partition_key", dataType: "text", position: 0, clusteringOrder: "none"},
+		{name: "ts", kind: "clustering", dataType: "timestamp", position: 0, clusteringOrder: "desc"},
+		{name: "seq", kind: "clustering", dataType: "bigint", position: 1, clusteringOrder: "asc"},
+		{name: "body", kind: "regular", dataType: "text", position: -1, clusteringOrder: "none"},
+	}
+	assert.Empty(t, diffTableColumns(schema.definition.columns, live))
+	live[1].clusteringOrder = "asc"
+	assert.Equal(t, ErrClusteringOrder, diffTableColumns(schema.definition.columns, live)[0].Err)
+
+	liveOptions := liveTableOptions{
+		comment:        "user's timeline",
+		defaultTTL:     86400,
+		gcGraceSeconds: 3600,
+		compaction: map[string]string{
+			"class":                  "org.apache.cassandra.db.compaction.TimeWindowCompactionStrategy",
+			"compaction_window_unit": "DAYS",
+			"max_threshold":          "32",
+		},
+	}
+	assert.Empty(t, diffTableOptions(schema.definition.options, liveOptions))
+
+	liveOptions.gcGraceSeconds = 864000
+	liveOptions.compaction["class"] = "org.apache.cassandra.db.compaction.SizeTieredCompactionStrategy"
+	mismatches := diffTableOptions(schema.definition.options, liveOptions)
+	assert.Len(t, mismatches, 2)
+	assert.Equal(t, "gc_grace_seconds: table option changed (model: 3600, table: 864000)", mismatches[0].String())
+	assert.Equal(t, "compaction.class", mismatches[1].Column)
+	err = &SchemaError{Table: "testtimeline", Mismatches: mismatches}
+	assert.True(t, errors.Is(err, ErrTableOptionChanged))
+}
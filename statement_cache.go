@@ -0,0 +1,110 @@
+package nosqlorm
+
+import (
+	"strings"
+	"sync"
+)
+
+// Cache of generated CQL text, keyed by model type and the shape of present columns.
+// The table is left out of the key, so tables and keyspaces of the same model share the cached text.
+// Only the bind values are collected on a cache hit.
+var statementCache sync.Map
+
+// Stands for the table in cached statement text, it could not clash with an unquoted identifier
+const tableToken = "{table}"
+
+type statementKey struct {
+	model     string
+	statement string
+	// Bitmap of the fields taking part in the statement
+	shape string
+	// IF, USING and projection clauses, which change the statement text
+	clauses string
+}
+
+type selectStatement struct {
+	sql          string
+	selectFields []string
+}
+
+// Get statement text of the table from the cache, render and cache it on a miss.
+// Errors are not cached, they are returned on every call.
+func cachedStatement(key statementKey, table string, render func(tableRef string) (string, error)) (string, error) {
+	if cached, ok := statementCache.Load(key); ok {
+		return strings.Replace(cached.(string), tableToken, table, 1), nil
+	}
+	sql, err := render(tableToken)
+	if err != nil {
+		return "", err
+	}
+	statementCache.Store(key, sql)
+	return strings.Replace(sql, tableToken, table, 1), nil
+}
+
+// Same as cachedStatement, the scanned columns of a SELECT are cached along with the text.
+func cachedSelect(key statementKey, table string, render func(tableRef string) (string, []string, error)) (selectStatement, error) {
+	if cached, ok := statementCache.Load(key); ok {
+		entry := cached.(selectStatement)
+		return selectStatement{sql: strings.Replace(entry.sql, tableToken, table, 1), selectFields: entry.selectFields}, nil
+	}
+	sql, selectFields, err := render(tableToken)
+	if err != nil {
+		return selectStatement{}, err
+	}
+	statementCache.Store(key, selectStatement{sql: sql, selectFields: selectFields})
+	return selectStatement{sql: strings.Replace(sql, tableToken, table, 1), selectFields: selectFields}, nil
+}
+
+// Bitmap of present fields, indexed by the position of the field in tableSchema.fields
+type columnShape []byte
+
+func newColumnShape(size int) columnShape {
+	return make(columnShape, (size+7)/8)
+}
+
+func (shape columnShape) set(i int) {
+	shape[i/8] |= 1 << (i % 8)
+}
+
+func (shape columnShape) isSet(i int) bool {
+	return shape[i/8]&(1<<(i%8)) != 0
+}
+
+func (shape columnShape) String() string {
+	return string(shape)
+}
+
+// Names of the present columns, in field order
+func (shape columnShape) columns(schema tableSchema) []string {
+	columns := make([]string, 0, len(schema.fields))
+	for i, fieldName := range schema.fields {
+		if shape.isSet(i) {
+			columns = append(columns, fieldName)
+		}
+	}
+	return columns
+}
+
+// Part of the cache key describing the IF and USING clauses of a write statement
+func (opts statementOptions) cacheKey() string {
+	if !opts.ifNotExists && !opts.ifExists && len(opts.conditions) == 0 && opts.ttl == nil && opts.timestamp == nil {
+		return ""
+	}
+	var key strings.Builder
+	if opts.ifNotExists {
+		key.WriteString("ifNotExists;")
+	}
+	if opts.ifExists {
+		key.WriteString("ifExists;")
+	}
+	for _, cond := range opts.conditions {
+		key.WriteString(cond.Column + " " + cond.Op + ";")
+	}
+	if opts.ttl != nil {
+		key.WriteString("ttl;")
+	}
+	if opts.timestamp != nil {
+		key.WriteString("timestamp;")
+	}
+	return key.String()
+}
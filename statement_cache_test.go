@@ -0,0 +1,74 @@
+package nosqlorm
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+type testCachedPerson struct {
+	Name    string  `json:"name" cql:"pk"`
+	Age     int8    `json:"age" cql:"ck"`
+	Address *string `json:"address"`
+}
+
+func Test_StatementCache(t *testing.T) {
+	_, err := NewCqlOrm[testCachedPerson](nil)
+	assert.NoError(t, err)
+
+	countEntries := func() int {
+		count := 0
+		statementCache.Range(func(key, value any) bool {
+			if key.(statementKey).model == reflect.TypeOf(testCachedPerson{}).String() {
+				count++
+			}
+			return true
+		})
+		return count
+	}
+
+	sql1, values1, err := buildInsertStatement(reflect.ValueOf(testCachedPerson{Name: "tony", Age: 30, Address: GetPointer("a")}), statementOptions{})
+	assert.NoError(t, err)
+	sql2, values2, err := buildInsertStatement(reflect.ValueOf(testCachedPerson{Name: "tom", Age: 20, Address: GetPointer("b")}), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, sql1, sql2)
+	assert.Equal(t, []interface{}{"tony", int8(30), "a"}, values1)
+	assert.Equal(t, []interface{}{"tom", int8(20), "b"}, values2)
+	assert.Equal(t, 1, countEntries())
+
+	// A different column shape is a different statement
+	sql3, _, err := buildInsertStatement(reflect.ValueOf(testCachedPerson{Name: "tom", Age: 20}), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO testcachedperson (name,age) VALUES (?,?);", sql3)
+	assert.Equal(t, 2, countEntries())
+
+	// Validation errors are not cached
+	for i := 0; i < 2; i++ {
+		_, _, _, err = buildSelectStatement(reflect.ValueOf(testCachedPerson{Age: 20}), "", &FieldMask{Filter: []string{"age"}})
+		assert.True(t, errors.Is(err, ErrMissingPartitionKey))
+	}
+	assert.Equal(t, 2, countEntries())
+
+	// Tables and keyspaces of the same model share the cached text
+	for _, table := range []string{"tenant_a.testcachedperson", "tenant_b.testcachedperson", "testcachedperson_2024"} {
+		sql, _, err := buildInsertStatement(reflect.ValueOf(testCachedPerson{Name: "tom", Age: 20}), statementOptions{table: table})
+		assert.NoError(t, err)
+		assert.Equal(t, "INSERT INTO "+table+" (name,age) VALUES (?,?);", sql)
+		sql, _, _, err = buildSelectStatement(reflect.ValueOf(testCachedPerson{Name: "tom", Age: 20}), table, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT name, age, address FROM "+table+" WHERE name=? AND age=?;", sql)
+	}
+	assert.Equal(t, 3, countEntries())
+}
+
+func Test_ColumnShape(t *testing.T) {
+	schema := tableSchema{fields: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}}
+	shape := newColumnShape(len(schema.fields))
+	assert.Equal(t, 2, len(shape))
+	shape.set(0)
+	shape.set(8)
+	assert.True(t, shape.isSet(8))
+	assert.False(t, shape.isSet(1))
+	assert.Equal(t, []string{"a", "i"}, shape.columns(schema))
+}
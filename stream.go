@@ -0,0 +1,49 @@
+package nosqlorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// ErrStopIteration Could be returned by a SelectEach callback to stop iterating without an error.
+var ErrStopIteration = errors.New("stop iteration")
+
+// SelectEach Stream matching rows to the callback one at a time instead of loading all of them into memory.
+// Iteration stops when the callback returns an error, which is returned unless it is ErrStopIteration.
+func (ctx *cqlOrm[T]) SelectEach(obj T, fn func(T) error) error {
+	return ctx.SelectEachCtx(context.Background(), obj, fn)
+}
+
+// SelectEachCtx is the same as SelectEach, the query is bound to the given context.
+func (ctx *cqlOrm[T]) SelectEachCtx(c context.Context, obj T, fn func(T) error) error {
+	sql, selectFields, whereValues, err := buildSelectStatement(reflect.ValueOf(obj), ctx.table(), nil)
+	if err != nil {
+		return err
+	}
+	return ctx.selectEach(c, sql, selectFields, whereValues, fn)
+}
+
+func (ctx *cqlOrm[T]) selectEach(c context.Context, sql string, selectFields []string, values []interface{}, fn func(T) error) error {
+	var t T
+	schema, _, err := loadTableSchema(reflect.TypeOf(t), "")
+	if err != nil {
+		return err
+	}
+
+	iter := ctx.sess.Query(sql, values...).WithContext(c).Iter()
+	for {
+		var tableObj T
+		if !iter.Scan(fieldPointers(&tableObj, selectFields, schema)...) {
+			break
+		}
+		if err := fn(tableObj); err != nil {
+			iter.Close()
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+	return iter.Close()
+}
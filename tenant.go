@@ -0,0 +1,78 @@
+package nosqlorm
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gocql/gocql"
+	"strings"
+	"sync"
+)
+
+// WithKeyspace Qualify the table names of all statements by the keyspace instead of relying on the keyspace of the session.
+// The keyspace is lower-cased like an unquoted CQL identifier.
+func WithKeyspace(keyspace string) OrmOption {
+	return func(opts *ormOptions) {
+		opts.keyspace = strings.ToLower(keyspace)
+	}
+}
+
+// InKeyspace Create an ORM object bound to the keyspace, it shares the session, options and cached schema of the original.
+func (ctx *cqlOrm[T]) InKeyspace(keyspace string) (*cqlOrm[T], error) {
+	if err := validateKeyspaceName(keyspace); err != nil {
+		return nil, err
+	}
+	orm := &cqlOrm[T]{sess: ctx.sess, opts: ctx.opts}
+	orm.opts.keyspace = strings.ToLower(keyspace)
+	return orm, nil
+}
+
+// Table reference used in statements, qualified by the keyspace of the ORM object.
+// Empty means the table of the model in the keyspace of the session.
+func (ctx *cqlOrm[T]) table() string {
+	if ctx.opts.keyspace == "" {
+		return ctx.opts.tableName
+	}
+	return ctx.opts.keyspace + "." + ctx.tableName()
+}
+
+// Keyspace the ORM object is bound to, the keyspace of the session by default
+func (ctx *cqlOrm[T]) keyspace() string {
+	if ctx.opts.keyspace != "" {
+		return ctx.opts.keyspace
+	}
+	return ctx.sess.Query("").Keyspace()
+}
+
+// TenantRouter hands out ORM objects bound to per-tenant keyspaces of one shared session.
+// Objects are created once per tenant and share the cached schema of the model.
+type TenantRouter[T interface{}] struct {
+	base       *cqlOrm[T]
+	keyspaceOf func(tenant string) string
+	tenants    sync.Map
+}
+
+// NewTenantRouter Create a tenant router, keyspaceOf maps a tenant to its keyspace such as "tenant_" + tenant.
+func NewTenantRouter[T interface{}](session *gocql.Session, keyspaceOf func(tenant string) string, options ...OrmOption) (*TenantRouter[T], error) {
+	if keyspaceOf == nil {
+		return nil, errors.New("Invalid tenant router: keyspaceOf must not be nil")
+	}
+	base, err := NewCqlOrm[T](session, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &TenantRouter[T]{base: base, keyspaceOf: keyspaceOf}, nil
+}
+
+// For Get the ORM object of a tenant, the keyspace must be a valid unquoted CQL identifier.
+func (r *TenantRouter[T]) For(tenant string) (NoSqlOrm[T], error) {
+	if orm, ok := r.tenants.Load(tenant); ok {
+		return orm.(*cqlOrm[T]), nil
+	}
+	keyspace := r.keyspaceOf(tenant)
+	tenantOrm, err := r.base.InKeyspace(keyspace)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid keyspace %s of tenant %s", keyspace, tenant))
+	}
+	orm, _ := r.tenants.LoadOrStore(tenant, tenantOrm)
+	return orm.(*cqlOrm[T]), nil
+}
@@ -0,0 +1,79 @@
+package nosqlorm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_InKeyspace(t *testing.T) {
+	orm, err := NewCqlOrm[testUserEvent](nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", orm.table())
+
+	tenant, err := orm.InKeyspace("tenant_a")
+	assert.NoError(t, err)
+	assert.Equal(t, "", orm.opts.keyspace)
+	assert.Equal(t, "tenant_a.user_events", tenant.table())
+	sql, _, err := buildInsertStatement(reflect.ValueOf(testUserEvent{UserId: "u1", Ts: time.Now()}), statementOptions{table: tenant.table()})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO tenant_a.user_events (user_id,ts,kind) VALUES (?,?,?);", sql)
+	sql, _, _, err = buildSelectStatement(reflect.ValueOf(testUserEvent{UserId: "u1"}), tenant.table(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT user_id, ts, kind FROM tenant_a.user_events WHERE user_id=? AND ts=?;", sql)
+	sql, _, _, err = tenant.Query().Where(Eq("user_id", "u1")).build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT user_id, ts, kind FROM tenant_a.user_events WHERE user_id=?;", sql)
+	ddl, err := tenant.GenerateDDL()
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS tenant_a.user_events (user_id text, ts timestamp, kind text, PRIMARY KEY (user_id, ts));\n", ddl)
+
+	// Keyspace and table name overrides are combined
+	monthly, err := NewCqlOrm[testUserEvent](nil, WithKeyspace("tenant_b"), WithTableName("user_events_202401"))
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant_b.user_events_202401", monthly.table())
+	sql, _, err = buildDeleteStatement(reflect.ValueOf(testUserEvent{UserId: "u1"}), statementOptions{table: monthly.table()})
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETE FROM tenant_b.user_events_202401 WHERE user_id=? AND ts=?;", sql)
+
+	// Keyspaces are validated and lower-cased like unquoted identifiers
+	_, err = orm.InKeyspace("a; DROP")
+	assert.EqualError(t, err, "Invalid keyspace a; DROP")
+	_, err = NewCqlOrm[testUserEvent](nil, WithKeyspace("a; DROP"))
+	assert.Error(t, err)
+	tenant, err = orm.InKeyspace("Tenant_C")
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant_c.user_events", tenant.table())
+}
+
+func Test_GenerateDDLInKeyspace(t *testing.T) {
+	ddl, err := GenerateDDLInKeyspace("tenant_a", testUserEvent{})
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS tenant_a.user_events (user_id text, ts timestamp, kind text, PRIMARY KEY (user_id, ts));\n", ddl)
+	_, err = GenerateDDLInKeyspace("tenant-a", testUserEvent{})
+	assert.Error(t, err)
+}
+
+func Test_TenantRouter(t *testing.T) {
+	router, err := NewTenantRouter[testUserEvent](nil, func(tenant string) string {
+		return "tenant_" + tenant
+	})
+	assert.NoError(t, err)
+
+	a, err := router.For("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant_a.user_events", a.(*cqlOrm[testUserEvent]).table())
+	again, err := router.For("a")
+	assert.NoError(t, err)
+	assert.Same(t, a, again)
+	b, err := router.For("b")
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant_b.user_events", b.(*cqlOrm[testUserEvent]).table())
+
+	_, err = router.For("x-y")
+	assert.EqualError(t, err, "Invalid keyspace tenant_x-y of tenant x-y")
+
+	_, err = NewTenantRouter[testUserEvent](nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+package nosqlorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gocql/gocql"
+	"reflect"
+	"strings"
+)
+
+type cqlUnitOfWork struct {
+	sess  *gocql.Session
+	batch *gocql.Batch
+	unitOfWorkOptions
+}
+
+type unitOfWorkOptions struct {
+	// Keyspace qualifying all tables, empty means the keyspace of the session
+	keyspace string
+	// Explicit tables of model types, the table of the model is used otherwise
+	tables map[reflect.Type]string
+}
+
+// UnitOfWorkOption Bind the writes of a unit of work to explicit tables or a keyspace
+type UnitOfWorkOption func(*unitOfWorkOptions)
+
+// WithUnitOfWorkKeyspace Qualify the tables of all writes by the keyspace, such as the keyspace of a tenant.
+func WithUnitOfWorkKeyspace(keyspace string) UnitOfWorkOption {
+	return func(opts *unitOfWorkOptions) {
+		opts.keyspace = strings.ToLower(keyspace)
+	}
+}
+
+// WithUnitOfWorkTable Write objects of the model type to an explicit table, like WithTableName of an ORM object.
+func WithUnitOfWorkTable(model interface{}, tableName string) UnitOfWorkOption {
+	return func(opts *unitOfWorkOptions) {
+		typ := reflect.TypeOf(model)
+		if typ != nil && typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		opts.tables[typ] = strings.ToLower(tableName)
+	}
+}
+
+// NewUnitOfWork Create a unit of work which accepts writes of any registered model type,
+// all collected writes are committed as one logged batch.
+func NewUnitOfWork(session *gocql.Session, options ...UnitOfWorkOption) UnitOfWork {
+	u := &cqlUnitOfWork{sess: session, batch: session.NewBatch(gocql.LoggedBatch)}
+	u.tables = make(map[reflect.Type]string)
+	for _, option := range options {
+		option(&u.unitOfWorkOptions)
+	}
+	return u
+}
+
+func (u *cqlUnitOfWork) Insert(obj interface{}) error {
+	return u.add(buildInsertStatement, obj)
+}
+
+func (u *cqlUnitOfWork) Update(obj interface{}) error {
+	return u.add(buildUpdateStatement, obj)
+}
+
+func (u *cqlUnitOfWork) Delete(obj interface{}) error {
+	return u.add(buildDeleteStatement, obj)
+}
+
+// Commit Run all collected writes as one logged batch.
+func (u *cqlUnitOfWork) Commit() error {
+	return u.CommitCtx(context.Background())
+}
+
+// CommitCtx is the same as Commit, the batch is bound to the given context for cancellation and deadlines.
+func (u *cqlUnitOfWork) CommitCtx(c context.Context) error {
+	return u.sess.ExecuteBatch(u.batch.WithContext(c))
+}
+
+func (u *cqlUnitOfWork) add(build func(reflect.Value, statementOptions) (string, []interface{}, error), obj interface{}) error {
+	// Accept both model values and pointers of models
+	val := reflect.ValueOf(obj)
+	if val.Kind() == reflect.Ptr && val.IsNil() {
+		val = reflect.Value{}
+	}
+	val = reflect.Indirect(val)
+	if !val.IsValid() {
+		return errors.New(fmt.Sprintf("Invalid model %T: must not be nil", obj))
+	}
+	sql, sqlValues, err := build(val, statementOptions{table: u.table(val.Type())})
+	if err != nil {
+		return err
+	}
+	u.batch.Query(sql, sqlValues...)
+	return nil
+}
+
+// Table reference of a model type, empty means the table of the model in the keyspace of the session
+func (opts unitOfWorkOptions) table(typ reflect.Type) string {
+	tableName := opts.tables[typ]
+	if opts.keyspace == "" {
+		return tableName
+	}
+	if tableName == "" {
+		tableName = modelTableName(typ)
+	}
+	return opts.keyspace + "." + tableName
+}
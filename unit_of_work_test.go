@@ -0,0 +1,37 @@
+package nosqlorm
+
+import (
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+func Test_UnitOfWorkTables(t *testing.T) {
+	assert.NoError(t, RegisterModels(testPerson{}, testUserEvent{}))
+	newUnitOfWork := func(options ...UnitOfWorkOption) *cqlUnitOfWork {
+		u := &cqlUnitOfWork{batch: &gocql.Batch{}}
+		u.tables = make(map[reflect.Type]string)
+		for _, option := range options {
+			option(&u.unitOfWorkOptions)
+		}
+		return u
+	}
+
+	u := newUnitOfWork()
+	assert.NoError(t, u.Insert(testPerson{Name: "tony", Age: 30}))
+	assert.NoError(t, u.Delete(&testUserEvent{UserId: "u1"}))
+	assert.Equal(t, "INSERT INTO testperson (name,age) VALUES (?,?);", u.batch.Entries[0].Stmt)
+	assert.Equal(t, "DELETE FROM user_events WHERE user_id=? AND ts=?;", u.batch.Entries[1].Stmt)
+
+	u = newUnitOfWork(WithUnitOfWorkKeyspace("tenant_a"), WithUnitOfWorkTable(&testUserEvent{}, "user_events_202401"))
+	assert.NoError(t, u.Insert(testPerson{Name: "tony", Age: 30}))
+	assert.NoError(t, u.Delete(testUserEvent{UserId: "u1"}))
+	assert.Equal(t, "INSERT INTO tenant_a.testperson (name,age) VALUES (?,?);", u.batch.Entries[0].Stmt)
+	assert.Equal(t, "DELETE FROM tenant_a.user_events_202401 WHERE user_id=? AND ts=?;", u.batch.Entries[1].Stmt)
+
+	// Nil objects are rejected instead of panicking
+	assert.EqualError(t, u.Insert(nil), "Invalid model <nil>: must not be nil")
+	assert.EqualError(t, u.Update((*testPerson)(nil)), "Invalid model *nosqlorm.testPerson: must not be nil")
+	assert.Len(t, u.batch.Entries, 2)
+}
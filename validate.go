@@ -0,0 +1,68 @@
+package nosqlorm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrMissingPartitionKey  = errors.New("missing partition key columns")
+	ErrMissingClusteringKey = errors.New("missing clustering key columns")
+	ErrClusteringKeyPrefix  = errors.New("clustering key columns must be restricted as a prefix")
+)
+
+// PrimaryKeyError is returned when a statement does not restrict the primary key the way Cassandra requires,
+// use errors.Is with ErrMissingPartitionKey, ErrMissingClusteringKey or ErrClusteringKeyPrefix to tell the reason.
+type PrimaryKeyError struct {
+	Table     string
+	Statement string
+	Missing   []string
+	Err       error
+}
+
+func (e *PrimaryKeyError) Error() string {
+	return fmt.Sprintf("%s on %s: %s: %s", e.Statement, e.Table, e.Err.Error(), strings.Join(e.Missing, ", "))
+}
+
+func (e *PrimaryKeyError) Unwrap() error {
+	return e.Err
+}
+
+// Check restricted key columns against the primary key of the table. All partition key columns must be restricted,
+// clustering key columns must be all restricted if fullKey is set, otherwise they must form a prefix.
+func checkPrimaryKey(schema tableSchema, tableName string, statement string, restricted map[string]bool, fullKey bool) error {
+	missing := make([]string, 0)
+	for _, key := range schema.partitionKeys {
+		if !restricted[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return &PrimaryKeyError{Table: tableName, Statement: statement, Missing: missing, Err: ErrMissingPartitionKey}
+	}
+
+	for i, key := range schema.clusteringKeys {
+		if restricted[key] {
+			continue
+		}
+		if fullKey {
+			missing = append(missing, key)
+			continue
+		}
+		// A gap is only allowed if no following clustering key is restricted
+		for _, next := range schema.clusteringKeys[i+1:] {
+			if restricted[next] {
+				missing = append(missing, key)
+				break
+			}
+		}
+	}
+	if len(missing) > 0 && fullKey {
+		return &PrimaryKeyError{Table: tableName, Statement: statement, Missing: missing, Err: ErrMissingClusteringKey}
+	}
+	if len(missing) > 0 {
+		return &PrimaryKeyError{Table: tableName, Statement: statement, Missing: missing, Err: ErrClusteringKeyPrefix}
+	}
+	return nil
+}
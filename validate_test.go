@@ -0,0 +1,68 @@
+package nosqlorm
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testMetric struct {
+	Host   *string    `json:"host" cql:"pk"`
+	Region *string    `json:"region" cql:"pk"`
+	Day    *time.Time `json:"day" cql:"ck,date"`
+	Ts     *time.Time `json:"ts" cql:"ck"`
+	Owner  *string    `json:"owner" cql:"static"`
+	Value  *float64   `json:"value"`
+}
+
+func Test_CheckPrimaryKey(t *testing.T) {
+	orm, err := NewCqlOrm[testMetric](nil)
+	assert.NoError(t, err)
+	now := time.Now()
+	host, region := GetPointer("web-1"), GetPointer("eu")
+
+	_, _, _, err = buildSelectStatement(reflect.ValueOf(testMetric{Host: host}), "", nil)
+	var keyErr *PrimaryKeyError
+	assert.True(t, errors.As(err, &keyErr))
+	assert.True(t, errors.Is(err, ErrMissingPartitionKey))
+	assert.Equal(t, []string{"region"}, keyErr.Missing)
+	assert.Equal(t, "SELECT on testmetric: missing partition key columns: region", err.Error())
+
+	_, _, _, err = buildSelectStatement(reflect.ValueOf(testMetric{Host: host, Region: region, Ts: &now}), "", nil)
+	assert.True(t, errors.Is(err, ErrClusteringKeyPrefix))
+	assert.True(t, errors.As(err, &keyErr))
+	assert.Equal(t, []string{"day"}, keyErr.Missing)
+
+	_, _, _, err = buildSelectStatement(reflect.ValueOf(testMetric{Host: host, Region: region, Day: &now}), "", nil)
+	assert.NoError(t, err)
+
+	_, _, err = buildUpdateStatement(reflect.ValueOf(testMetric{Host: host, Region: region, Day: &now, Value: GetPointer(1.0)}), statementOptions{})
+	assert.True(t, errors.Is(err, ErrMissingClusteringKey))
+	// Static columns only need the partition key
+	_, _, err = buildUpdateStatement(reflect.ValueOf(testMetric{Host: host, Region: region, Owner: GetPointer("tony")}), statementOptions{})
+	assert.NoError(t, err)
+
+	_, _, err = buildInsertStatement(reflect.ValueOf(testMetric{Host: host, Region: region, Value: GetPointer(1.0)}), statementOptions{})
+	assert.True(t, errors.Is(err, ErrMissingClusteringKey))
+
+	_, _, err = buildDeleteStatement(reflect.ValueOf(testMetric{Host: host, Region: region}), statementOptions{})
+	assert.NoError(t, err)
+	_, _, err = buildDeleteStatement(reflect.ValueOf(testMetric{Host: host, Region: region}), statementOptions{ifExists: true})
+	assert.True(t, errors.Is(err, ErrMissingClusteringKey))
+
+	// Query builder
+	_, _, _, err = orm.Query().build()
+	assert.NoError(t, err)
+	_, _, _, err = orm.Query().Where(Eq("host", "web-1")).build()
+	assert.True(t, errors.Is(err, ErrMissingPartitionKey))
+	_, _, _, err = orm.Query().OrderBy(Desc("day")).build()
+	assert.True(t, errors.Is(err, ErrMissingPartitionKey))
+	_, _, _, err = orm.Query().Where(Eq("host", "web-1"), Eq("region", "eu"), Gt("ts", now)).build()
+	assert.True(t, errors.Is(err, ErrClusteringKeyPrefix))
+	_, _, _, err = orm.Query().Where(Eq("host", "web-1"), Eq("region", "eu"), Gt("day", now), Gt("ts", now)).build()
+	assert.Error(t, err)
+	_, _, _, err = orm.Query().Where(Eq("host", "web-1"), Eq("region", "eu"), Eq("day", now), Gt("ts", now), Lt("ts", now)).build()
+	assert.NoError(t, err)
+}
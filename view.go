@@ -0,0 +1,197 @@
+package nosqlorm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gocql/gocql"
+	"reflect"
+	"strings"
+)
+
+var ErrReadOnlyView = errors.New("materialized views are read-only")
+
+// ModelView could be implemented by models of materialized views, BaseModel returns a value of the base table model.
+// Columns of the view are selected from the base table, its primary key must contain every primary key column
+// of the base table and at most one other column.
+type ModelView interface {
+	BaseModel() interface{}
+}
+
+// Validate the view against its base model and return the base table definition
+func viewBaseDefinition(def tableDefinition, baseModel interface{}) (tableDefinition, error) {
+	base, err := modelTableDefinition(baseModel)
+	if err != nil {
+		return tableDefinition{}, err
+	}
+	if base.base != nil {
+		return tableDefinition{}, errors.New(fmt.Sprintf("Invalid view %s: base model %s is a view", def.name, base.name))
+	}
+	if def.options.DefaultTTL > 0 {
+		return tableDefinition{}, errors.New(fmt.Sprintf("Invalid view %s: default TTL is not allowed on views", def.name))
+	}
+
+	baseColumns := make(map[string]CqlColumn, len(base.columns))
+	for _, column := range base.columns {
+		if column.Type == "counter" {
+			return tableDefinition{}, errors.New(fmt.Sprintf("Invalid view %s: views of counter tables are not supported", def.name))
+		}
+		baseColumns[column.Name] = column
+	}
+	viewKeys := make(map[string]bool)
+	extraKeys := make([]string, 0)
+	for _, column := range def.columns {
+		if column.Name == "-" {
+			continue
+		}
+		baseColumn, ok := baseColumns[column.Name]
+		switch {
+		case !ok:
+			return tableDefinition{}, errors.New(fmt.Sprintf("Invalid view %s: column %s not found in base table %s", def.name, column.Name, base.name))
+		case baseColumn.Type != column.Type:
+			return tableDefinition{}, errors.New(fmt.Sprintf("Invalid view %s: column %s is %s in base table %s", def.name, column.Name, baseColumn.Type, base.name))
+		case column.Static || column.Index != "":
+			return tableDefinition{}, errors.New(fmt.Sprintf("Invalid view %s: static or indexed column %s is not allowed in views", def.name, column.Name))
+		}
+		isKey := column.PartitionKey || column.ClusteringKey
+		if isKey {
+			viewKeys[column.Name] = true
+		}
+		if isKey && !baseColumn.PartitionKey && !baseColumn.ClusteringKey {
+			extraKeys = append(extraKeys, column.Name)
+		}
+	}
+	for _, column := range base.columns {
+		if (column.PartitionKey || column.ClusteringKey) && !viewKeys[column.Name] {
+			return tableDefinition{}, errors.New(fmt.Sprintf("Invalid view %s: primary key must contain %s of base table %s", def.name, column.Name, base.name))
+		}
+	}
+	if len(extraKeys) > 1 {
+		return tableDefinition{}, errors.New(fmt.Sprintf("Invalid view %s: at most one non primary key column of the base table could be in the primary key, got %s",
+			def.name, strings.Join(extraKeys, ", ")))
+	}
+	return base, nil
+}
+
+// WithBaseTableName Select a view from an explicit base table, such as a table created with WithTableName.
+// The name is lower-cased like an unquoted CQL identifier.
+func WithBaseTableName(tableName string) OrmOption {
+	return func(opts *ormOptions) {
+		opts.baseTableName = strings.ToLower(tableName)
+	}
+}
+
+// Render the CREATE MATERIALIZED VIEW statement, the base table is in the keyspace of the view
+func createViewStatement(def tableDefinition) (string, error) {
+	fields := make([]string, 0, len(def.columns))
+	notNull := make([]string, 0)
+	for _, column := range def.columns {
+		if column.Name == "-" {
+			continue
+		}
+		fields = append(fields, column.Name)
+		if column.PartitionKey || column.ClusteringKey {
+			notNull = append(notNull, column.Name+" IS NOT NULL")
+		}
+	}
+	primaryKey, err := primaryKeyClause(def.name, def.columns)
+	if err != nil {
+		return "", err
+	}
+	base := tableDefinition{keyspace: def.keyspace, name: def.base.name}
+	return fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS %s AS SELECT %s FROM %s WHERE %s PRIMARY KEY %s%s;", def.qualifiedName(),
+		strings.Join(fields, ", "), base.qualifiedName(), strings.Join(notNull, " AND "), primaryKey, renderTableOptions(def.columns, def.options)), nil
+}
+
+// Load the table schema of a write statement, views could not be written
+func loadWritableSchema(typ reflect.Type, table string) (tableSchema, string, error) {
+	schema, tableName, err := loadTableSchema(typ, table)
+	if err == nil && schema.definition.base != nil {
+		return tableSchema{}, tableName, ErrReadOnlyView
+	}
+	return schema, tableName, err
+}
+
+var _ NoSqlView[struct{}] = (*cqlView[struct{}])(nil)
+
+// cqlView is the read-only ORM object of a materialized view, it has no write methods
+type cqlView[T interface{}] struct {
+	orm *cqlOrm[T]
+}
+
+// NewCqlView Create a new object to read a materialized view, T must implement ModelView.
+func NewCqlView[T interface{}](session *gocql.Session, options ...OrmOption) (*cqlView[T], error) {
+	var t T
+	if _, ok := interface{}(&t).(ModelView); !ok {
+		return nil, errors.New(fmt.Sprintf("Invalid view %T: must implement ModelView", t))
+	}
+	orm, err := newCqlOrm[T](session, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &cqlView[T]{orm: orm}, nil
+}
+
+// InKeyspace Create a view object bound to the keyspace, see cqlOrm.InKeyspace.
+func (v *cqlView[T]) InKeyspace(keyspace string) (*cqlView[T], error) {
+	orm, err := v.orm.InKeyspace(keyspace)
+	if err != nil {
+		return nil, err
+	}
+	return &cqlView[T]{orm: orm}, nil
+}
+
+func (v *cqlView[T]) Select(obj T) ([]T, error) {
+	return v.orm.Select(obj)
+}
+
+func (v *cqlView[T]) SelectCtx(c context.Context, obj T) ([]T, error) {
+	return v.orm.SelectCtx(c, obj)
+}
+
+func (v *cqlView[T]) SelectFields(obj T, mask FieldMask) ([]T, error) {
+	return v.orm.SelectFields(obj, mask)
+}
+
+func (v *cqlView[T]) SelectFieldsCtx(c context.Context, obj T, mask FieldMask) ([]T, error) {
+	return v.orm.SelectFieldsCtx(c, obj, mask)
+}
+
+func (v *cqlView[T]) SelectPage(obj T, pageSize int, cursor []byte) ([]T, []byte, error) {
+	return v.orm.SelectPage(obj, pageSize, cursor)
+}
+
+func (v *cqlView[T]) SelectPageCtx(c context.Context, obj T, pageSize int, cursor []byte) ([]T, []byte, error) {
+	return v.orm.SelectPageCtx(c, obj, pageSize, cursor)
+}
+
+func (v *cqlView[T]) SelectEach(obj T, fn func(T) error) error {
+	return v.orm.SelectEach(obj, fn)
+}
+
+func (v *cqlView[T]) SelectEachCtx(c context.Context, obj T, fn func(T) error) error {
+	return v.orm.SelectEachCtx(c, obj, fn)
+}
+
+func (v *cqlView[T]) Find(spec QuerySpec) ([]T, error) {
+	return v.orm.Find(spec)
+}
+
+func (v *cqlView[T]) FindCtx(c context.Context, spec QuerySpec) ([]T, error) {
+	return v.orm.FindCtx(c, spec)
+}
+
+// Query Start a query on the view, see cqlOrm.Query.
+func (v *cqlView[T]) Query() *cqlQuery[T] {
+	return v.orm.Query()
+}
+
+// GenerateDDL Render the CREATE MATERIALIZED VIEW statement of the view.
+func (v *cqlView[T]) GenerateDDL() (string, error) {
+	return v.orm.GenerateDDL()
+}
+
+// CreateView Create the view if it does not exist, views could not be altered so an existing view must match the model.
+func (v *cqlView[T]) CreateView() error {
+	return v.orm.CreateTable()
+}
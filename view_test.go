@@ -0,0 +1,119 @@
+package nosqlorm
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testOrder struct {
+	Customer string    `json:"customer" cql:"pk"`
+	Id       string    `json:"id" cql:"ck"`
+	Status   string    `json:"status"`
+	Created  time.Time `json:"created"`
+	Total    float64   `json:"total"`
+}
+
+type testOrderByStatus struct {
+	Status   string    `json:"status" cql:"pk"`
+	Customer string    `json:"customer" cql:"ck"`
+	Id       string    `json:"id" cql:"ck,desc"`
+	Created  time.Time `json:"created"`
+	Total    float64   `json:"total"`
+}
+
+func (testOrderByStatus) BaseModel() interface{} {
+	return testOrder{}
+}
+
+func (testOrderByStatus) TableName() string {
+	return "orders_by_status"
+}
+
+// Two non primary key columns of the base table in the view key
+type testInvalidOrderView struct {
+	Status   string    `json:"status" cql:"pk"`
+	Created  time.Time `json:"created" cql:"ck"`
+	Customer string    `json:"customer" cql:"ck"`
+	Id       string    `json:"id" cql:"ck"`
+}
+
+func (testInvalidOrderView) BaseModel() interface{} {
+	return testOrder{}
+}
+
+func Test_ViewDDL(t *testing.T) {
+	ddl, err := GenerateDDL(testOrder{}, testOrderByStatus{})
+	assert.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS testorder (customer text, id text, status text, created timestamp, total double, PRIMARY KEY (customer, id));\n"+
+		"CREATE MATERIALIZED VIEW IF NOT EXISTS orders_by_status AS SELECT status, customer, id, created, total FROM testorder "+
+		"WHERE status IS NOT NULL AND customer IS NOT NULL AND id IS NOT NULL "+
+		"PRIMARY KEY (status, customer, id) WITH CLUSTERING ORDER BY (customer ASC, id DESC);\n", ddl)
+
+	ddl, err = GenerateDDLInKeyspace("shop", testOrderByStatus{})
+	assert.NoError(t, err)
+	assert.Contains(t, ddl, "CREATE MATERIALIZED VIEW IF NOT EXISTS shop.orders_by_status AS SELECT status, customer, id, created, total FROM shop.testorder WHERE")
+
+	_, err = GenerateDDL(testInvalidOrderView{})
+	assert.EqualError(t, err, "Invalid view testinvalidorderview: at most one non primary key column of the base table could be in the primary key, got status, created")
+}
+
+func Test_ViewValidation(t *testing.T) {
+	def := tableDefinition{name: "orders_by_status"}
+	var err error
+	def.columns, err = modelColumns(reflect.TypeOf(testOrderByStatus{}))
+	assert.NoError(t, err)
+
+	// Base primary key column left out of the view key
+	def.columns[2].ClusteringKey = false
+	_, err = viewBaseDefinition(def, testOrder{})
+	assert.EqualError(t, err, "Invalid view orders_by_status: primary key must contain id of base table testorder")
+
+	def.columns[2].ClusteringKey = true
+	def.columns[4].Type = "float"
+	_, err = viewBaseDefinition(def, testOrder{})
+	assert.EqualError(t, err, "Invalid view orders_by_status: column total is double in base table testorder")
+
+	def.columns[4] = CqlColumn{Name: "discount", Type: "double"}
+	_, err = viewBaseDefinition(def, testOrder{})
+	assert.EqualError(t, err, "Invalid view orders_by_status: column discount not found in base table testorder")
+}
+
+func Test_ReadOnlyView(t *testing.T) {
+	_, err := NewCqlOrm[testOrderByStatus](nil)
+	assert.EqualError(t, err, "Invalid model nosqlorm.testOrderByStatus: materialized views are read-only, use NewCqlView")
+
+	view, err := NewCqlView[testOrderByStatus](nil)
+	assert.NoError(t, err)
+	_, err = NewCqlView[testOrder](nil)
+	assert.Error(t, err)
+
+	sql, _, _, err := buildSelectStatement(reflect.ValueOf(testOrderByStatus{Status: "shipped", Customer: "c1", Id: "o1"}), view.orm.table(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT status, customer, id, created, total FROM orders_by_status WHERE status=? AND customer=? AND id=?;", sql)
+	sql, _, _, err = view.Query().Where(Eq("status", "shipped"), Eq("customer", "c1"), Gt("id", "o100")).Limit(20).build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT status, customer, id, created, total FROM orders_by_status WHERE status=? AND customer=? AND id>? LIMIT ?;", sql)
+
+	// Writes through non-generic APIs are rejected as well
+	_, _, err = buildInsertStatement(reflect.ValueOf(testOrderByStatus{Status: "shipped"}), statementOptions{})
+	assert.True(t, errors.Is(err, ErrReadOnlyView))
+	_, _, err = buildDeleteStatement(reflect.ValueOf(testOrderByStatus{Status: "shipped"}), statementOptions{})
+	assert.True(t, errors.Is(err, ErrReadOnlyView))
+}
+
+func Test_ViewBaseTableName(t *testing.T) {
+	view, err := NewCqlView[testOrderByStatus](nil, WithKeyspace("shop"), WithTableName("orders_by_status_2024"), WithBaseTableName("Orders_2024"))
+	assert.NoError(t, err)
+	ddl, err := view.GenerateDDL()
+	assert.NoError(t, err)
+	assert.Contains(t, ddl, "CREATE MATERIALIZED VIEW IF NOT EXISTS shop.orders_by_status_2024 AS SELECT status, customer, id, created, total FROM shop.orders_2024 WHERE")
+
+	// Only views have a base table
+	_, err = NewCqlOrm[testOrder](nil, WithBaseTableName("orders_2024"))
+	assert.EqualError(t, err, "Invalid table testorder: only views have a base table")
+	_, err = NewCqlView[testOrderByStatus](nil, WithBaseTableName("orders (id text PRIMARY KEY); DROP TABLE users; --"))
+	assert.EqualError(t, err, "Invalid base table name orders (id text primary key); drop table users; --")
+}
@@ -0,0 +1,147 @@
+package nosqlorm
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gocql/gocql"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ModelTTL Could be implemented by a model to declare the default TTL of all its writes.
+type ModelTTL interface {
+	DefaultTTL() time.Duration
+}
+
+// NullPolicy decides how nil pointers and zero values of non-key columns are written.
+type NullPolicy int
+
+const (
+	// OmitNulls Leave nil pointer fields out of the statement, this is the default policy.
+	OmitNulls NullPolicy = iota
+	// UnsetNulls Bind nil pointer fields as gocql.UnsetValue, so the statement text stays the same for every object.
+	// Requires native protocol v4 or later.
+	UnsetNulls
+	// WriteNulls Write nil pointer fields as NULL, which clears the column and creates a tombstone.
+	WriteNulls
+	// ZeroAsNull Write nil pointer fields and zero values of non-pointer fields as NULL.
+	ZeroAsNull
+)
+
+type writeOptions struct {
+	ttl        *time.Duration
+	timestamp  *time.Time
+	nullPolicy NullPolicy
+}
+
+// WriteOption Add USING clauses to INSERT and UPDATE statements
+type WriteOption func(*writeOptions)
+
+// WithTTL Expire the written columns after the given duration, zero means never expire.
+func WithTTL(ttl time.Duration) WriteOption {
+	return func(opts *writeOptions) {
+		opts.ttl = &ttl
+	}
+}
+
+// WithTimestamp Use a client supplied write timestamp instead of the server time.
+func WithTimestamp(timestamp time.Time) WriteOption {
+	return func(opts *writeOptions) {
+		opts.timestamp = &timestamp
+	}
+}
+
+// WithNullPolicy Decide how nil pointers and zero values are written, see NullPolicy.
+func WithNullPolicy(policy NullPolicy) WriteOption {
+	return func(opts *writeOptions) {
+		opts.nullPolicy = policy
+	}
+}
+
+// WithDefaultWriteOptions Apply write options to every INSERT and UPDATE of the ORM object, per call options take precedence.
+func WithDefaultWriteOptions(options ...WriteOption) OrmOption {
+	return func(opts *ormOptions) {
+		for _, option := range options {
+			option(&opts.writeOptions)
+		}
+	}
+}
+
+// Merge per call options into the default ones of the ORM object
+func (opts writeOptions) with(options []WriteOption) writeOptions {
+	for _, option := range options {
+		option(&opts)
+	}
+	return opts
+}
+
+// Render USING clause, the model level default TTL is used if no TTL was given.
+func (opts writeOptions) renderUsing(schema tableSchema) string {
+	clauses := make([]string, 0, 2)
+	if opts.ttl != nil || schema.defaultTTL != nil {
+		clauses = append(clauses, "TTL ?")
+	}
+	if opts.timestamp != nil {
+		clauses = append(clauses, "TIMESTAMP ?")
+	}
+	if len(clauses) == 0 {
+		return ""
+	}
+	return " USING " + strings.Join(clauses, " AND ")
+}
+
+// Bind values of the USING clause, in the order they are rendered
+func (opts writeOptions) usingValues(schema tableSchema) ([]interface{}, error) {
+	ttl := opts.ttl
+	if ttl == nil {
+		ttl = schema.defaultTTL
+	}
+	values := make([]interface{}, 0, 2)
+	if ttl != nil {
+		seconds, err := ttlSeconds(*ttl)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, seconds)
+	}
+	if opts.timestamp != nil {
+		values = append(values, opts.timestamp.UnixMicro())
+	}
+	return values, nil
+}
+
+// Convert a TTL to the seconds Cassandra expects, a fraction would be truncated and zero means never expire.
+func ttlSeconds(ttl time.Duration) (int, error) {
+	if ttl < 0 || ttl%time.Second != 0 {
+		return 0, errors.New(fmt.Sprintf("Invalid TTL %s: must be a whole number of seconds", ttl))
+	}
+	return int(ttl / time.Second), nil
+}
+
+// Bind value of a non-key column under the field mask and null policy, false means the column is left out of the statement.
+// Nil pointers of columns named in a field mask are always written as NULL.
+func (opts statementOptions) writeValue(fieldVal interface{}, field tableField) (interface{}, bool) {
+	if opts.mask != nil && !opts.mask[field.fieldName] {
+		return nil, false
+	}
+	if opts.nullPolicy == ZeroAsNull && !field.isPointer && fieldVal != nil && reflect.ValueOf(fieldVal).IsZero() {
+		fieldVal = nil
+	}
+	if fieldVal != nil {
+		return fieldVal, true
+	}
+	if field.isCounter {
+		// Counter columns could not be set to NULL
+		return nil, false
+	}
+	switch {
+	case opts.mask != nil:
+		return nil, true
+	case opts.nullPolicy == UnsetNulls:
+		return gocql.UnsetValue, true
+	case opts.nullPolicy == WriteNulls || opts.nullPolicy == ZeroAsNull:
+		return nil, true
+	}
+	return nil, false
+}
@@ -0,0 +1,117 @@
+package nosqlorm
+
+import (
+	"context"
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testSession struct {
+	Token string `json:"token" cql:"pk"`
+	User  string `json:"user"`
+}
+
+func (testSession) DefaultTTL() time.Duration {
+	return time.Hour
+}
+
+func Test_BuildStatementsWithWriteOptions(t *testing.T) {
+	_, err := NewCqlOrm[testPerson](nil)
+	assert.NoError(t, err)
+	_, err = NewCqlOrm[testSession](nil)
+	assert.NoError(t, err)
+
+	ts := time.UnixMicro(1700000000000000)
+	person := testPerson{Name: "tony", Age: 30, Address: GetPointer("street")}
+	opts := writeOptions{}.with([]WriteOption{WithTTL(time.Minute), WithTimestamp(ts)})
+
+	sql, values, err := buildInsertStatement(reflect.ValueOf(person), statementOptions{writeOptions: opts})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO testperson (name,age,address) VALUES (?,?,?) USING TTL ? AND TIMESTAMP ?;", sql)
+	assert.Equal(t, []interface{}{"tony", int8(30), "street", 60, ts.UnixMicro()}, values)
+
+	sql, values, err = buildUpdateStatement(reflect.ValueOf(person), statementOptions{writeOptions: opts})
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE testperson USING TTL ? AND TIMESTAMP ? SET address=? WHERE name=? AND age=?;", sql)
+	assert.Equal(t, []interface{}{60, ts.UnixMicro(), "street", "tony", int8(30)}, values)
+
+	// Model level default TTL, overridden by per call TTL
+	session := testSession{Token: "abc", User: "tony"}
+	sql, values, err = buildInsertStatement(reflect.ValueOf(session), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO testsession (token,user) VALUES (?,?) USING TTL ?;", sql)
+	assert.Equal(t, []interface{}{"abc", "tony", 3600}, values)
+
+	_, values, err = buildInsertStatement(reflect.ValueOf(session), statementOptions{writeOptions: writeOptions{}.with([]WriteOption{WithTTL(0)})})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"abc", "tony", 0}, values)
+
+	// A fraction of a second would be truncated, sub-second TTLs would never expire
+	for _, ttl := range []time.Duration{500 * time.Millisecond, 1500 * time.Millisecond, -time.Second} {
+		_, _, err = buildInsertStatement(reflect.ValueOf(person), statementOptions{writeOptions: writeOptions{}.with([]WriteOption{WithTTL(ttl)})})
+		assert.EqualError(t, err, "Invalid TTL "+ttl.String()+": must be a whole number of seconds")
+		_, _, err = buildUpdateStatement(reflect.ValueOf(person), statementOptions{writeOptions: writeOptions{}.with([]WriteOption{WithTTL(ttl)})})
+		assert.Error(t, err)
+	}
+}
+
+func Test_DefaultWriteOptions(t *testing.T) {
+	orm, err := NewCqlOrm[testPerson](nil, WithDefaultWriteOptions(WithTTL(time.Minute)))
+	assert.NoError(t, err)
+	opts := orm.opts.writeOptions.with([]WriteOption{WithTTL(time.Second)})
+	assert.Equal(t, time.Second, *opts.ttl)
+	assert.Equal(t, time.Minute, *orm.opts.writeOptions.ttl)
+}
+
+func Test_NullPolicy(t *testing.T) {
+	_, err := NewCqlOrm[testProfile](nil)
+	assert.NoError(t, err)
+	profile := testProfile{Name: "tony", Age: 30, Address: "", Score: 10}
+	policy := func(p NullPolicy) statementOptions {
+		return statementOptions{writeOptions: writeOptions{}.with([]WriteOption{WithNullPolicy(p)})}
+	}
+
+	sql, values, err := buildInsertStatement(reflect.ValueOf(profile), statementOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO testprofile (name,age,address,score) VALUES (?,?,?,?);", sql)
+	assert.Equal(t, []interface{}{"tony", int8(30), "", 10}, values)
+
+	sql, values, err = buildInsertStatement(reflect.ValueOf(profile), policy(UnsetNulls))
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO testprofile (name,age,address,score,note) VALUES (?,?,?,?,?);", sql)
+	assert.Equal(t, []interface{}{"tony", int8(30), "", 10, gocql.UnsetValue}, values)
+
+	sql, values, err = buildUpdateStatement(reflect.ValueOf(profile), policy(WriteNulls))
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE testprofile SET address=?,score=?,note=? WHERE name=? AND age=?;", sql)
+	assert.Equal(t, []interface{}{"", 10, nil, "tony", int8(30)}, values)
+
+	sql, values, err = buildUpdateStatement(reflect.ValueOf(profile), policy(ZeroAsNull))
+	assert.NoError(t, err)
+	assert.Equal(t, "UPDATE testprofile SET address=?,score=?,note=? WHERE name=? AND age=?;", sql)
+	assert.Equal(t, []interface{}{nil, 10, nil, "tony", int8(30)}, values)
+
+	// Key columns are never affected by the null policy
+	_, values, err = buildInsertStatement(reflect.ValueOf(testProfile{Name: "tony"}), policy(ZeroAsNull))
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"tony", int8(0), nil, nil, nil}, values)
+}
+
+func Test_MockWriteOptions(t *testing.T) {
+	table := NewMockTable[testPerson](NewMockSession(t))
+	person := testPerson{Name: "tony", Age: 30}
+	table.AddWriteExpectation(person, []WriteOption{WithTTL(time.Minute)}, nil)
+	table.AddOtherExpectation(person, nil)
+	assert.NoError(t, table.Insert(person, WithTTL(time.Minute)))
+	// Options are not checked by AddOtherExpectation
+	assert.NoError(t, table.UpdateCtx(context.Background(), person, WithTTL(time.Hour)))
+
+	mockT := &testing.T{}
+	table = NewMockTable[testPerson](NewMockSession(mockT))
+	table.AddWriteExpectation(person, []WriteOption{WithTTL(time.Minute)}, nil)
+	assert.NoError(t, table.Update(person, WithTTL(time.Hour)))
+	assert.True(t, mockT.Failed())
+}